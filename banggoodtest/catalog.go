@@ -0,0 +1,580 @@
+package banggoodtest
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// Product states reported by getProductUpdateList.
+const (
+	StateUpdated  = client.ProductStateUpdated
+	StateOffShelf = client.ProductStateOffShelf
+	StateDeleted  = client.ProductStateDeleted
+)
+
+// Catalog is the data a Server serves.
+type Catalog struct {
+	Categories []client.Category
+	Products   []Product
+	Countries  []client.Country
+	Brands     []Brand
+}
+
+// Product bundles everything the API reports about one product.
+type Product struct {
+	client.Product
+
+	Info      client.GetProductInfoResponse
+	Stocks    []client.WarehouseStock
+	Price     client.GetProductPriceResponse
+	Shipments []client.ShipMethod
+	// State is reported by getProductUpdateList; 0 means StateUpdated.
+	State int
+}
+
+type Brand struct {
+	client.LimitPriceBrand
+
+	Products []client.BrandLimitPrice
+}
+
+type catalog struct {
+	categories []client.Category
+	products   map[string]*Product
+	countries  []client.Country
+	brands     []Brand
+}
+
+func newCatalog() catalog {
+	return catalog{
+		products: map[string]*Product{},
+	}
+}
+
+// sortedProducts returns the catalog's products ordered by ID, so pages are
+// stable between requests.
+func (c catalog) sortedProducts() []*Product {
+	products := make([]*Product, 0, len(c.products))
+	for _, p := range c.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ProductID < products[j].ProductID
+	})
+	return products
+}
+
+// Seed replaces the server's catalog.
+func (s *Server) Seed(c Catalog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog = newCatalog()
+	s.catalog.categories = append(s.catalog.categories, c.Categories...)
+	s.catalog.countries = append(s.catalog.countries, c.Countries...)
+	s.catalog.brands = append(s.catalog.brands, c.Brands...)
+	for _, p := range c.Products {
+		s.putProduct(p)
+	}
+}
+
+// PutProduct adds or replaces a product and stamps its modify date with the
+// server clock, so it shows up in the update list.
+func (s *Server) PutProduct(p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.ModifyDate = client.NewBanggoodTime(s.now())
+	s.putProduct(p)
+}
+
+func (s *Server) putProduct(p Product) {
+	if p.AddDate.IsZero() {
+		p.AddDate = client.NewBanggoodTime(s.now())
+	}
+	if p.ModifyDate.IsZero() {
+		p.ModifyDate = p.AddDate
+	}
+	if p.Info.ProductName == "" {
+		p.Info.ProductName = p.ProductName
+	}
+	s.catalog.products[p.ProductID] = p.clone()
+}
+
+// clone returns a copy of p that shares no slices with it, so neither the
+// server nor its callers see the other's changes.
+func (p Product) clone() *Product {
+	c := p
+	c.Info.PoaList = nil
+	for _, option := range p.Info.PoaList {
+		option.OptionValues = append([]client.OptionValue(nil), option.OptionValues...)
+		c.Info.PoaList = append(c.Info.PoaList, option)
+	}
+	c.Info.WarehouseList = append([]client.ProductWarehouse(nil), p.Info.WarehouseList...)
+	c.Info.ImageList = append([]client.Image(nil), p.Info.ImageList...)
+	c.Stocks = nil
+	for _, w := range p.Stocks {
+		w.StocksList = append([]client.PoaStock(nil), w.StocksList...)
+		c.Stocks = append(c.Stocks, w)
+	}
+	c.Shipments = append([]client.ShipMethod(nil), p.Shipments...)
+	return &c
+}
+
+// SetProductState marks a product off-shelf or deleted. Deleted products
+// disappear from listings but remain in the update list.
+func (s *Server) SetProductState(productID string, state int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.catalog.products[productID]; ok {
+		p.State = state
+		p.ModifyDate = client.NewBanggoodTime(s.now())
+	}
+}
+
+// SetStock sets the stock of productID's poaID in warehouse.
+func (s *Server) SetStock(productID, warehouse string, poaID, stock int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.catalog.products[productID]
+	if !ok {
+		return
+	}
+	p.ModifyDate = client.NewBanggoodTime(s.now())
+	for i := range p.Stocks {
+		if p.Stocks[i].Warehouse != warehouse {
+			continue
+		}
+		for j := range p.Stocks[i].StocksList {
+			if p.Stocks[i].StocksList[j].PoaID == poaID {
+				p.Stocks[i].StocksList[j].Stock = strconv.Itoa(stock)
+				return
+			}
+		}
+		p.Stocks[i].StocksList = append(p.Stocks[i].StocksList, client.PoaStock{PoaID: poaID, Stock: strconv.Itoa(stock)})
+		return
+	}
+	p.Stocks = append(p.Stocks, client.WarehouseStock{
+		Warehouse:  warehouse,
+		StocksList: []client.PoaStock{{PoaID: poaID, Stock: strconv.Itoa(stock)}},
+	})
+}
+
+func (s *Server) product(r *http.Request) (*Product, *Fault) {
+	id := r.URL.Query().Get("product_id")
+	if id == "" {
+		return nil, badParameter("product_id is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.catalog.products[id]
+	if !ok || p.State == StateDeleted {
+		return nil, badParameter("product %s does not exist", id)
+	}
+	return p.clone(), nil
+}
+
+func (s *Server) translate(r *http.Request) (interface{}, *Fault) {
+	if _, fault := s.product(r); fault != nil {
+		return nil, fault
+	}
+	return client.TranslateResponse{}, nil
+}
+
+// getProductPrice serves p.Price if it is set, and otherwise prices the
+// requested warehouse plus the requested POAs from the product info.
+func (s *Server) getProductPrice(r *http.Request) (interface{}, *Fault) {
+	p, fault := s.product(r)
+	if fault != nil {
+		return nil, fault
+	}
+	if !p.Price.ProductPrice.IsZero() {
+		return p.Price, nil
+	}
+	q := r.URL.Query()
+	var price client.Money
+	found := false
+	for _, w := range p.Info.WarehouseList {
+		if w.Warehouse == q.Get("warehouse") || (q.Get("warehouse") == "" && !found) {
+			price, found = w.WarehousePrice, true
+		}
+	}
+	if !found {
+		return nil, badParameter("product %s is not sold from warehouse %s", p.ProductID, q.Get("warehouse"))
+	}
+	for _, poaID := range strings.Split(q.Get("poa_id"), ",") {
+		if poaID == "" {
+			continue
+		}
+		value, ok := findPoa(p.Info, poaID)
+		if !ok {
+			return nil, badParameter("poa_id %s does not exist", poaID)
+		}
+		sum, err := price.Add(value.PoaPrice)
+		if err != nil {
+			return nil, &Fault{Message: err.Error(), HTTPStatus: http.StatusInternalServerError}
+		}
+		price = sum
+	}
+	res := p.Price
+	res.Currency = price.Currency
+	res.ProductPrice = price
+	return res, nil
+}
+
+func findPoa(info client.GetProductInfoResponse, poaID string) (client.OptionValue, bool) {
+	for _, option := range info.PoaList {
+		for _, value := range option.OptionValues {
+			if value.PoaID == poaID {
+				return value, true
+			}
+		}
+	}
+	return client.OptionValue{}, false
+}
+
+func (s *Server) getCategoryList(r *http.Request) (interface{}, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, from, to, fault := s.pageBounds(r, len(s.catalog.categories))
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetCategoryListResponse{
+		Page:          page,
+		CategoryTotal: len(s.catalog.categories),
+		Language:      lang(r),
+		CategoryList:  s.catalog.categories[from:to],
+	}, nil
+}
+
+func (s *Server) getProductList(r *http.Request) (interface{}, *Fault) {
+	q := r.URL.Query()
+	categoryID := q.Get("cat_id")
+	bounds := map[string]time.Time{}
+	for _, key := range []string{"add_date_start", "add_date_end", "modify_date_start", "modify_date_end"} {
+		if raw := q.Get(key); raw != "" {
+			t, err := client.ParseBanggoodTime(raw)
+			if err != nil {
+				return nil, badParameter("%s %q is invalid", key, raw)
+			}
+			bounds[key] = t.Time
+		}
+	}
+	within := func(t client.BanggoodTime, start, end string) bool {
+		if b, ok := bounds[start]; ok && t.Before(b) {
+			return false
+		}
+		if b, ok := bounds[end]; ok && t.After(b) {
+			return false
+		}
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []client.Product
+	for _, p := range s.catalog.sortedProducts() {
+		if p.State == StateDeleted || p.State == StateOffShelf {
+			continue
+		}
+		if categoryID != "" && strconv.Itoa(p.CategoryID) != categoryID {
+			continue
+		}
+		if !within(p.AddDate, "add_date_start", "add_date_end") || !within(p.ModifyDate, "modify_date_start", "modify_date_end") {
+			continue
+		}
+		matched = append(matched, p.Product)
+	}
+	page, from, to, fault := s.pageBounds(r, len(matched))
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetProductListResponse{
+		Page:         page,
+		ProductTotal: len(matched),
+		Language:     lang(r),
+		ProductList:  matched[from:to],
+	}, nil
+}
+
+func (s *Server) getProductInfo(r *http.Request) (interface{}, *Fault) {
+	p, fault := s.product(r)
+	if fault != nil {
+		return nil, fault
+	}
+	info := p.Info
+	info.Language = lang(r)
+	return info, nil
+}
+
+func (s *Server) getShipments(r *http.Request) (interface{}, *Fault) {
+	p, fault := s.product(r)
+	if fault != nil {
+		return nil, fault
+	}
+	q := r.URL.Query()
+	if q.Get("country") == "" {
+		return nil, badParameter("country is required")
+	}
+	if quantity, err := strconv.Atoi(q.Get("quantity")); err != nil || quantity < 1 {
+		return nil, badParameter("quantity %q is invalid", q.Get("quantity"))
+	}
+	if len(p.Shipments) == 0 {
+		return nil, badParameter("product %s cannot be shipped to %s", p.ProductID, q.Get("country"))
+	}
+	res := client.GetShipmentsResponse{Currency: "USD", ShipMethods: p.Shipments}
+	if currency := q.Get("currency"); currency != "" {
+		res.Currency = currency
+	}
+	return res, nil
+}
+
+func (s *Server) getStocks(r *http.Request) (interface{}, *Fault) {
+	p, fault := s.product(r)
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetStockResponse{
+		Stocks:   p.Stocks,
+		Code:     "0",
+		Language: lang(r),
+	}, nil
+}
+
+func (s *Server) getProductUpdateList(r *http.Request) (interface{}, *Fault) {
+	minutes, err := strconv.Atoi(r.URL.Query().Get("minutes"))
+	if err != nil || minutes < 1 {
+		return nil, badParameter("minutes %q is invalid", r.URL.Query().Get("minutes"))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	since := s.now().Add(-time.Duration(minutes) * time.Minute)
+	var updates []client.ProductUpdate
+	for _, p := range s.catalog.sortedProducts() {
+		if p.ModifyDate.Before(since) {
+			continue
+		}
+		state := p.State
+		if state == 0 {
+			state = StateUpdated
+		}
+		updates = append(updates, client.ProductUpdate{
+			ProductID:  p.ProductID,
+			State:      state,
+			ModifyDate: p.ModifyDate,
+		})
+	}
+	page, from, to, fault := s.pageBounds(r, len(updates))
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetProductUpdateListResponse{
+		Page:              page,
+		ProductTotal:      len(updates),
+		Language:          lang(r),
+		UpdateProductList: updates[from:to],
+	}, nil
+}
+
+func (s *Server) getCountries(r *http.Request) (interface{}, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return client.GetCountriesResponse{
+		Countries: s.catalog.countries,
+	}, nil
+}
+
+func (s *Server) getLimitPriceBrand(r *http.Request) (interface{}, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	brands := make([]client.LimitPriceBrand, len(s.catalog.brands))
+	for i, b := range s.catalog.brands {
+		brands[i] = b.LimitPriceBrand
+	}
+	page, from, to, fault := s.pageBounds(r, len(brands))
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetLimitPriceBrandResponse{
+		Page:       page,
+		BrandTotal: len(brands),
+		Language:   lang(r),
+		BrandList:  brands[from:to],
+	}, nil
+}
+
+func (s *Server) getBrandLimitPriceList(r *http.Request) (interface{}, *Fault) {
+	brandID := r.URL.Query().Get("brand_id")
+	if brandID == "" {
+		return nil, badParameter("brand_id is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range s.catalog.brands {
+		if b.BrandID != brandID {
+			continue
+		}
+		page, from, to, fault := s.pageBounds(r, len(b.Products))
+		if fault != nil {
+			return nil, fault
+		}
+		return client.GetBrandLimitPriceListResponse{
+			Page:         page,
+			ProductTotal: len(b.Products),
+			Language:     lang(r),
+			ProductList:  b.Products[from:to],
+		}, nil
+	}
+	return nil, badParameter("brand %s does not exist", brandID)
+}
+
+// GenerateCatalog builds a deterministic catalog from seed with the given
+// number of categories and products per category.
+func GenerateCatalog(seed int64, categories, productsPerCategory int) Catalog {
+	rnd := rand.New(rand.NewSource(seed))
+	warehouses := []string{"CN", "US", "UK", "DE"}
+	colors := []string{"Black", "White", "Red", "Blue"}
+	sizes := []string{"S", "M", "L", "XL"}
+	added := time.Date(2020, 1, 1, 0, 0, 0, 0, client.BanggoodLocation)
+
+	c := Catalog{
+		Countries: []client.Country{
+			{CountryID: 223, CountryName: "United States"},
+			{CountryID: 222, CountryName: "United Kingdom"},
+			{CountryID: 81, CountryName: "Germany"},
+			{CountryID: 13, CountryName: "Australia"},
+		},
+	}
+
+	nextID := 1
+	for i := 0; i < categories; i++ {
+		categoryID := nextID
+		nextID++
+		parentID := "0"
+		if i > 0 && rnd.Intn(2) == 0 {
+			parentID = c.Categories[rnd.Intn(len(c.Categories))].CategoryID
+		}
+		c.Categories = append(c.Categories, client.Category{
+			CategoryID:   strconv.Itoa(categoryID),
+			CategoryName: fmt.Sprintf("Category %d", categoryID),
+			ParentID:     parentID,
+		})
+
+		for j := 0; j < productsPerCategory; j++ {
+			productID := strconv.Itoa(1000000 + len(c.Products))
+			base := 5 + rnd.Float64()*95
+			p := Product{
+				Product: client.Product{
+					ProductID:   productID,
+					CategoryID:  categoryID,
+					ProductName: fmt.Sprintf("Product %s", productID),
+					Image:       fmt.Sprintf("https://img.example.com/%s.jpg", productID),
+					AddDate:     client.NewBanggoodTime(added.Add(time.Duration(len(c.Products)) * time.Hour)),
+				},
+			}
+			p.ModifyDate = p.AddDate
+			p.Info = client.GetProductInfoResponse{
+				ProductName: p.ProductName,
+				Description: fmt.Sprintf("Description of product %s", productID),
+				Weight:      float32(0.1 + rnd.Float64()*2),
+			}
+
+			var values []client.OptionValue
+			for k, color := range colors[:1+rnd.Intn(len(colors))] {
+				values = append(values, client.OptionValue{
+					PoaID:    strconv.Itoa(100 + k),
+					PoaName:  color,
+					Poa:      color,
+					PoaPrice: client.NewMoney(int64(k)*100, 2, "USD"),
+				})
+			}
+			p.Info.PoaList = append(p.Info.PoaList, client.ProductOption{OptionID: 1, OptionName: "Color", OptionValues: values})
+			if rnd.Intn(2) == 0 {
+				values = nil
+				for k, size := range sizes[:1+rnd.Intn(len(sizes))] {
+					values = append(values, client.OptionValue{
+						PoaID:    strconv.Itoa(200 + k),
+						PoaName:  size,
+						Poa:      size,
+						PoaPrice: client.NewMoney(int64(k)*50, 2, "USD"),
+					})
+				}
+				p.Info.PoaList = append(p.Info.PoaList, client.ProductOption{OptionID: 2, OptionName: "Size", OptionValues: values})
+			}
+
+			for _, warehouse := range warehouses[:1+rnd.Intn(len(warehouses))] {
+				p.Info.WarehouseList = append(p.Info.WarehouseList, client.ProductWarehouse{
+					Warehouse:      warehouse,
+					WarehousePrice: client.NewMoney(int64(base*(1+rnd.Float64()*0.2)*100), 2, "USD"),
+				})
+				stock := client.WarehouseStock{Warehouse: warehouse}
+				for _, option := range p.Info.PoaList {
+					for _, value := range option.OptionValues {
+						poaID, _ := strconv.Atoi(value.PoaID)
+						count := rnd.Intn(50)
+						message := "In stock"
+						if count == 0 {
+							message = "Out of stock"
+						}
+						stock.StocksList = append(stock.StocksList, client.PoaStock{
+							PoaID:         poaID,
+							Poa:           value.Poa,
+							Stock:         strconv.Itoa(count),
+							StocksMessage: message,
+						})
+					}
+				}
+				p.Stocks = append(p.Stocks, stock)
+			}
+
+			p.Shipments = []client.ShipMethod{{
+				ShipMethodCode: "airmail",
+				ShipMethodName: "Air Parcel Register",
+				Shipday:        fmt.Sprintf("%d-%d business days", 7+rnd.Intn(5), 15+rnd.Intn(10)),
+				Shipfee:        client.NewMoney(int64(100+rnd.Intn(500)), 2, "USD"),
+			}, {
+				ShipMethodCode: "standard",
+				ShipMethodName: "Standard Shipping",
+				Shipday:        fmt.Sprintf("%d-%d business days", 15+rnd.Intn(5), 30+rnd.Intn(10)),
+				Shipfee:        client.NewMoney(int64(rnd.Intn(100)), 2, "USD"),
+			}}
+			if rnd.Intn(2) == 0 {
+				p.Shipments = append(p.Shipments, client.ShipMethod{
+					ShipMethodCode: "expedited",
+					ShipMethodName: "Expedited Shipping",
+					Shipday:        fmt.Sprintf("%d-%d business days", 3+rnd.Intn(2), 6+rnd.Intn(3)),
+					Shipfee:        client.NewMoney(int64(1500+rnd.Intn(1500)), 2, "USD"),
+				})
+			}
+			c.Products = append(c.Products, p)
+		}
+	}
+
+	for i := 0; i < 1+categories/4; i++ {
+		brand := Brand{
+			LimitPriceBrand: client.LimitPriceBrand{
+				BrandID: strconv.Itoa(500 + i),
+				Name:    fmt.Sprintf("Brand %d", i+1),
+			},
+		}
+		for j := i; j < len(c.Products); j += 1 + categories/4 {
+			p := c.Products[j]
+			brand.Products = append(brand.Products, client.BrandLimitPrice{
+				ProductID:  p.ProductID,
+				Sku:        "SKU" + p.ProductID,
+				Poa:        p.Info.PoaList[0].OptionValues[0].Poa,
+				LimitPrice: p.Info.WarehouseList[0].WarehousePrice.MulRatio(3, 2).RoundCurrency(),
+			})
+		}
+		c.Brands = append(c.Brands, brand)
+	}
+	return c
+}
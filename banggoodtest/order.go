@@ -0,0 +1,316 @@
+package banggoodtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+const (
+	StatusProcessing = "Processing"
+	StatusShipped    = "Shipped"
+	StatusDelivered  = "Delivered"
+	StatusCancelled  = "Cancelled"
+)
+
+type storedOrder struct {
+	order       client.Order
+	history     []client.OrderStatus
+	trackNumber string
+	track       []client.TrackEvent
+}
+
+type storedSaleRecord struct {
+	user   client.UserInfo
+	orders []*storedOrder
+}
+
+type orderStore struct {
+	seq         int
+	saleRecords map[string]*storedSaleRecord
+	orders      map[string]*storedOrder
+}
+
+func newOrderStore() orderStore {
+	return orderStore{
+		saleRecords: map[string]*storedSaleRecord{},
+		orders:      map[string]*storedOrder{},
+	}
+}
+
+// SetOrderStatus appends status to an order's history.
+func (s *Server) SetOrderStatus(orderID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.orders.orders[orderID]; ok {
+		o.order.Status = status
+		o.history = append(o.history, client.OrderStatus{Status: status, DateAdd: client.NewBanggoodTime(s.now()).String()})
+	}
+}
+
+// AddTrackEvent records a tracking event and assigns trackNumber to the order.
+func (s *Server) AddTrackEvent(orderID, trackNumber, event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.orders.orders[orderID]; ok {
+		o.trackNumber = trackNumber
+		o.track = append(o.track, client.TrackEvent{Event: event, Time: client.NewBanggoodTime(s.now()).String()})
+	}
+}
+
+// OrderIDs returns the IDs of the orders created for saleRecordID.
+func (s *Server) OrderIDs(saleRecordID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.orders.saleRecords[saleRecordID]
+	if !ok {
+		return nil
+	}
+	ids := make([]string, len(record.orders))
+	for i, o := range record.orders {
+		ids[i] = o.order.OrderID
+	}
+	return ids
+}
+
+func (s *Server) importOrder(r *http.Request) (interface{}, *Fault) {
+	if r.Method != http.MethodPost {
+		return nil, &Fault{Code: client.CodeInvalidParameter, Message: "importOrder requires POST", HTTPStatus: http.StatusMethodNotAllowed}
+	}
+	var req client.ImportOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, badParameter("malformed order: %v", err)
+	}
+	if fault := s.checkToken(req.AccessToken); fault != nil {
+		return nil, fault
+	}
+	if err := req.Validate(); err != nil {
+		return nil, badParameter("%v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders.saleRecords[req.SaleRecordID]; ok {
+		return nil, &Fault{Code: client.CodeDuplicateOrder, Message: fmt.Sprintf("sale_record_id %s already exists", req.SaleRecordID)}
+	}
+
+	res := client.ImportOrderResponse{
+		SaleRecordID: req.SaleRecordID,
+		ProductTotal: strconv.Itoa(len(req.ProductList)),
+		Code:         "0",
+	}
+	record := &storedSaleRecord{
+		user: client.UserInfo{
+			DeliveryName:           req.DeliveryName,
+			DeliveryCountry:        req.DeliveryCountry,
+			DeliveryState:          req.DeliveryState,
+			DeliveryCity:           req.DeliveryCity,
+			DeliveryStreetAddress:  req.DeliveryStreetAddress,
+			DeliveryStreetAddress2: req.DeliveryStreetAddress2,
+		},
+	}
+	for _, line := range req.ProductList {
+		o, reason := s.placeLine(req, line)
+		if reason != "" {
+			res.FailureList = append(res.FailureList, client.ImportOrderFailure{
+				ProductID:        line.ProductID,
+				PoaID:            line.PoaID,
+				Warehouse:        line.Warehouse,
+				Quantity:         line.Quantity,
+				ShipmethodCode:   line.ShipmethodCode,
+				ErrorDescription: reason,
+			})
+			continue
+		}
+		record.orders = append(record.orders, o)
+		s.orders.orders[o.order.OrderID] = o
+	}
+	if len(record.orders) > 0 {
+		s.orders.saleRecords[req.SaleRecordID] = record
+	}
+	res.SuccessTotal = strconv.Itoa(len(record.orders))
+	res.FailureTotal = strconv.Itoa(len(res.FailureList))
+	return res, nil
+}
+
+// placeLine reserves stock for one order line and creates its order. It
+// returns a failure reason when the line cannot be fulfilled.
+func (s *Server) placeLine(req client.ImportOrderRequest, line client.ImportOrderProduct) (*storedOrder, string) {
+	p, ok := s.catalog.products[line.ProductID]
+	if !ok || p.State == StateDeleted || p.State == StateOffShelf {
+		return nil, "product is not available"
+	}
+	quantity, _ := strconv.Atoi(line.Quantity)
+
+	methodOK := len(p.Shipments) == 0
+	var shipfee client.Money
+	for _, shipment := range p.Shipments {
+		if shipment.ShipMethodCode == line.ShipmethodCode {
+			methodOK = true
+			shipfee = shipment.Shipfee
+		}
+	}
+	if !methodOK {
+		return nil, "invalid shipmethod_code"
+	}
+
+	// A line with several POAs draws from the stock of each.
+	var stocks []*client.PoaStock
+	var warehouse string
+	for i := range p.Stocks {
+		if line.Warehouse != "" && p.Stocks[i].Warehouse != line.Warehouse {
+			continue
+		}
+		if candidates := reserveStock(p.Stocks[i].StocksList, line.PoaID, quantity); candidates != nil {
+			stocks, warehouse = candidates, p.Stocks[i].Warehouse
+			break
+		}
+	}
+	if stocks == nil {
+		return nil, "out of stock"
+	}
+	var price client.Money
+	for _, w := range p.Info.WarehouseList {
+		if w.Warehouse == warehouse {
+			price = w.WarehousePrice
+		}
+	}
+	for _, poaID := range strings.Split(line.PoaID, ",") {
+		if value, ok := findPoa(p.Info, strings.TrimSpace(poaID)); ok {
+			sum, err := price.Add(value.PoaPrice)
+			if err != nil {
+				return nil, err.Error()
+			}
+			price = sum
+		}
+	}
+	for _, stock := range stocks {
+		available, _ := strconv.Atoi(stock.Stock)
+		stock.Stock = strconv.Itoa(available - quantity)
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	s.orders.seq++
+	subAmount := price.Mul(int64(quantity)).In(currency)
+	shipfee = shipfee.In(currency)
+	o := &storedOrder{
+		order: client.Order{
+			OrderID:        fmt.Sprintf("%d", 70000000+s.orders.seq),
+			Status:         StatusProcessing,
+			Currency:       currency,
+			ShipmethodCode: line.ShipmethodCode,
+			SubAmount:      subAmount,
+			Shipfee:        shipfee,
+			TotalAmount:    subAmount.MustAdd(shipfee),
+			ProductList: []client.OrderProduct{{
+				ProductID: line.ProductID,
+				Warehouse: []string{warehouse},
+				Quantity:  quantity,
+				PoaID:     line.PoaID,
+			}},
+		},
+		history: []client.OrderStatus{{Status: StatusProcessing, DateAdd: client.NewBanggoodTime(s.now()).String()}},
+	}
+	return o, ""
+}
+
+func (s *Server) getOrderInfo(r *http.Request) (interface{}, *Fault) {
+	saleRecordID := r.URL.Query().Get("sale_record_id")
+	if saleRecordID == "" {
+		return nil, badParameter("sale_record_id is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := client.GetOrderInfoResponse{}
+	record, ok := s.orders.saleRecords[saleRecordID]
+	if !ok {
+		return res, nil
+	}
+	sr := client.SaleRecord{
+		SaleRecordID: saleRecordID,
+		UserInfo:     []client.UserInfo{record.user},
+	}
+	for _, o := range record.orders {
+		sr.OrderList = append(sr.OrderList, o.order)
+	}
+	res.SaleRecordIDList = append(res.SaleRecordIDList, sr)
+	return res, nil
+}
+
+func (s *Server) storedOrder(r *http.Request) (*storedOrder, *Fault) {
+	orderID := r.URL.Query().Get("order_id")
+	if orderID == "" {
+		return nil, badParameter("order_id is required")
+	}
+	o, ok := s.orders.orders[orderID]
+	if !ok {
+		return nil, badParameter("order %s does not exist", orderID)
+	}
+	return o, nil
+}
+
+func (s *Server) getTrackInfo(r *http.Request) (interface{}, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, fault := s.storedOrder(r)
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetTrackInfoResponse{
+		TrackInfo: o.track,
+		Code:      "0",
+	}, nil
+}
+
+func (s *Server) getOrderHistory(r *http.Request) (interface{}, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, fault := s.storedOrder(r)
+	if fault != nil {
+		return nil, fault
+	}
+	return client.GetOrderHistoryResponse{
+		OrderHistory: o.history,
+		TrackNumber:  o.trackNumber,
+		Code:         "0",
+	}, nil
+}
+
+// reserveStock returns the stock entries a line with poaID would draw
+// quantity from, or nil if any of them falls short.
+func reserveStock(list []client.PoaStock, poaID string, quantity int) []*client.PoaStock {
+	if poaID == "" {
+		for j := range list {
+			if n, _ := strconv.Atoi(list[j].Stock); n >= quantity {
+				return []*client.PoaStock{&list[j]}
+			}
+		}
+		return nil
+	}
+	var stocks []*client.PoaStock
+	for _, id := range strings.Split(poaID, ",") {
+		var found *client.PoaStock
+		for j := range list {
+			if strconv.Itoa(list[j].PoaID) == strings.TrimSpace(id) {
+				found = &list[j]
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		if n, _ := strconv.Atoi(found.Stock); n < quantity {
+			return nil
+		}
+		stocks = append(stocks, found)
+	}
+	return stocks
+}
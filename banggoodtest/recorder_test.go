@@ -0,0 +1,74 @@
+package banggoodtest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+func TestRecorderRecordsAndReplays(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Seed(GenerateCatalog(1, 2, 3))
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := srv.ManagedClient(client.WithHTTPClient(rec.HTTPClient()))
+	recorded, err := c.GetAllCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("cassette written before Save: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{DefaultAppID, DefaultAppSecret, "token-1"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	replay, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := client.NewManagedClient(
+		client.NewClient("other-id", "other-secret", client.WithBaseURL(srv.URL), client.WithHTTPClient(replay.HTTPClient()), client.WithRetryPolicy(client.RetryPolicy{})),
+		client.StaticTokenSource("other-token"),
+	)
+	srv.Close()
+	replayed, err := offline.GetAllCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(recorded) {
+		t.Errorf("replayed %d categories, recorded %d", len(replayed), len(recorded))
+	}
+
+	if _, err := offline.GetCountries(ctx); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("GetCountries = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestNewRecorderReplayMissingCassette(t *testing.T) {
+	if _, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay); !os.IsNotExist(err) {
+		t.Errorf("NewRecorder = %v, want not exist", err)
+	}
+}
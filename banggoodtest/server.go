@@ -0,0 +1,287 @@
+// Package banggoodtest provides an in-process fake of the Banggood API for
+// end-to-end tests that must not reach the network.
+package banggoodtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+const (
+	DefaultAppID     = "test-app-id"
+	DefaultAppSecret = "test-app-secret"
+	DefaultTokenTTL  = 2 * time.Hour
+	DefaultPageSize  = 20
+)
+
+// Fault is an error the server returns instead of handling a request.
+type Fault struct {
+	Code       int
+	Message    string
+	HTTPStatus int
+	// Times is how many consecutive requests fail; values below 1 mean once.
+	Times int
+}
+
+// Server is a fake Banggood API backed by an in-memory catalog and order
+// store. Configure the exported fields before issuing requests.
+type Server struct {
+	*httptest.Server
+
+	AppID     string
+	AppSecret string
+	TokenTTL  time.Duration
+	PageSize  int
+
+	mu       sync.Mutex
+	offset   time.Duration
+	tokens   map[string]time.Time
+	tokenSeq int
+	faults   map[string][]Fault
+	requests map[string]int
+	catalog  catalog
+	orders   orderStore
+}
+
+// NewServer starts a fake API with an empty catalog.
+func NewServer() *Server {
+	s := &Server{
+		AppID:     DefaultAppID,
+		AppSecret: DefaultAppSecret,
+		TokenTTL:  DefaultTokenTTL,
+		PageSize:  DefaultPageSize,
+		tokens:    map[string]time.Time{},
+		faults:    map[string][]Fault{},
+		requests:  map[string]int{},
+		catalog:   newCatalog(),
+		orders:    newOrderStore(),
+	}
+
+	mux := http.NewServeMux()
+	handle := func(endpoint string, h func(r *http.Request) (interface{}, *Fault)) {
+		mux.HandleFunc("/"+endpoint, s.wrap(endpoint, h))
+	}
+	handle(client.EndpointGetAccessToken, s.getAccessToken)
+	handle(client.EndpointTranslate, s.authed(s.translate))
+	handle(client.EndpointGetProductPrice, s.authed(s.getProductPrice))
+	handle(client.EndpointGetCategoryList, s.authed(s.getCategoryList))
+	handle(client.EndpointGetProductList, s.authed(s.getProductList))
+	handle(client.EndpointGetProductInfo, s.authed(s.getProductInfo))
+	handle(client.EndpointGetShipments, s.authed(s.getShipments))
+	handle(client.EndpointGetStocks, s.authed(s.getStocks))
+	handle(client.EndpointGetProductUpdateList, s.authed(s.getProductUpdateList))
+	handle(client.EndpointGetCountries, s.authed(s.getCountries))
+	handle(client.EndpointGetLimitPriceBrand, s.authed(s.getLimitPriceBrand))
+	handle(client.EndpointGetBrandLimitPriceList, s.authed(s.getBrandLimitPriceList))
+	handle(client.EndpointImportOrder, s.importOrder)
+	handle(client.EndpointGetOrderInfo, s.authed(s.getOrderInfo))
+	handle(client.EndpointGetTrackInfo, s.authed(s.getTrackInfo))
+	handle(client.EndpointGetOrderHistory, s.authed(s.getOrderHistory))
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BanggoodClient returns a client wired to the fake server. It does not
+// retry, so every injected fault reaches the caller; pass WithRetryPolicy to
+// test retries.
+func (s *Server) BanggoodClient(opts ...client.Option) client.BanggoodClient {
+	opts = append([]client.Option{
+		client.WithBaseURL(s.URL),
+		client.WithHTTPClient(s.Server.Client()),
+		client.WithRetryPolicy(client.RetryPolicy{}),
+	}, opts...)
+	return client.NewClient(s.AppID, s.AppSecret, opts...)
+}
+
+// ManagedClient returns a token-managing client wired to the fake server.
+func (s *Server) ManagedClient(opts ...client.Option) client.ManagedClient {
+	c := s.BanggoodClient(opts...)
+	return client.NewManagedClient(c, client.NewTokenSource(c))
+}
+
+// Now is the server's clock, which Advance moves forward.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// Advance moves the server clock forward, e.g. to expire tokens.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// IssueToken returns a fresh valid access token.
+func (s *Server) IssueToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken()
+}
+
+func (s *Server) issueToken() string {
+	s.tokenSeq++
+	token := fmt.Sprintf("token-%d", s.tokenSeq)
+	s.tokens[token] = s.now().Add(s.TokenTTL)
+	return token
+}
+
+// ExpireTokens makes every issued token expired.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token := range s.tokens {
+		s.tokens[token] = s.now().Add(-time.Second)
+	}
+}
+
+// InjectFault queues f for the next request to endpoint, e.g.
+// client.EndpointGetStocks. Faults are served in the order injected.
+func (s *Server) InjectFault(endpoint string, f Fault) {
+	if f.Times < 1 {
+		f.Times = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[endpoint] = append(s.faults[endpoint], f)
+}
+
+// Requests reports how many requests endpoint has received.
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func (s *Server) nextFault(endpoint string) *Fault {
+	queue := s.faults[endpoint]
+	if len(queue) == 0 {
+		return nil
+	}
+	f := queue[0]
+	queue[0].Times--
+	if queue[0].Times == 0 {
+		s.faults[endpoint] = queue[1:]
+	}
+	return &f
+}
+
+func (s *Server) wrap(endpoint string, h func(r *http.Request) (interface{}, *Fault)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		fault := s.nextFault(endpoint)
+		s.mu.Unlock()
+
+		var data interface{}
+		if fault == nil {
+			data, fault = h(r)
+		}
+		if fault != nil {
+			status := fault.HTTPStatus
+			if status == 0 {
+				status = http.StatusOK
+			}
+			writeJSON(w, status, map[string]interface{}{
+				"code": fault.Code,
+				"msg":  fault.Message,
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// authed rejects requests without a valid, unexpired access_token.
+func (s *Server) authed(h func(r *http.Request) (interface{}, *Fault)) func(r *http.Request) (interface{}, *Fault) {
+	return func(r *http.Request) (interface{}, *Fault) {
+		if fault := s.checkToken(r.URL.Query().Get("access_token")); fault != nil {
+			return nil, fault
+		}
+		return h(r)
+	}
+}
+
+func (s *Server) checkToken(token string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.tokens[token]
+	switch {
+	case !ok:
+		return &Fault{Code: client.CodeInvalidToken, Message: "access_token is invalid"}
+	case !s.now().Before(expiry):
+		return &Fault{Code: client.CodeTokenExpired, Message: "access_token has expired"}
+	}
+	return nil
+}
+
+func badParameter(format string, a ...interface{}) *Fault {
+	return &Fault{Code: client.CodeInvalidParameter, Message: fmt.Sprintf(format, a...)}
+}
+
+func (s *Server) getAccessToken(r *http.Request) (interface{}, *Fault) {
+	q := r.URL.Query()
+	if q.Get("app_id") != s.AppID {
+		return nil, &Fault{Code: client.CodeInvalidAppID, Message: "app_id is invalid"}
+	}
+	if q.Get("app_secret") != s.AppSecret {
+		return nil, &Fault{Code: client.CodeInvalidAppSecret, Message: "app_secret is invalid"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return client.GetAccessTokenResponse{
+		AccessToken: s.issueToken(),
+		ExpiresIn:   int(s.TokenTTL / time.Second),
+	}, nil
+}
+
+// pageBounds returns the slice bounds for the requested page of n items.
+func (s *Server) pageBounds(r *http.Request, n int) (client.Page, int, int, *Fault) {
+	page := 1
+	if raw := r.URL.Query().Get("page"); raw != "" {
+		var err error
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			return client.Page{}, 0, 0, badParameter("page %q is invalid", raw)
+		}
+	}
+	size := s.PageSize
+	if size < 1 {
+		size = DefaultPageSize
+	}
+	total := (n + size - 1) / size
+	from := (page - 1) * size
+	if from > n {
+		from = n
+	}
+	to := from + size
+	if to > n {
+		to = n
+	}
+	return client.Page{PageNumber: page, PageTotal: total, PageSize: size}, from, to, nil
+}
+
+func lang(r *http.Request) string {
+	if l := r.URL.Query().Get("lang"); l != "" {
+		return l
+	}
+	return "en"
+}
@@ -0,0 +1,280 @@
+package banggoodtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+func TestServerTokens(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.BanggoodClient()
+	ctx := context.Background()
+
+	res, err := c.GetAccessToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AccessToken != "token-1" || res.ExpiresIn != int(DefaultTokenTTL/time.Second) {
+		t.Errorf("GetAccessToken = %+v", res)
+	}
+	if _, err := c.GetCountries(ctx, res.AccessToken); err != nil {
+		t.Errorf("GetCountries with an issued token = %v", err)
+	}
+	if _, err := c.GetCountries(ctx, "forged"); !errors.Is(err, client.ErrInvalidToken) {
+		t.Errorf("GetCountries with a forged token = %v, want ErrInvalidToken", err)
+	}
+
+	srv.Advance(DefaultTokenTTL)
+	if _, err := c.GetCountries(ctx, res.AccessToken); !errors.Is(err, client.ErrTokenExpired) {
+		t.Errorf("GetCountries after the TTL = %v, want ErrTokenExpired", err)
+	}
+	fresh := srv.IssueToken()
+	srv.ExpireTokens()
+	if _, err := c.GetCountries(ctx, fresh); !errors.Is(err, client.ErrTokenExpired) {
+		t.Errorf("GetCountries after ExpireTokens = %v, want ErrTokenExpired", err)
+	}
+
+	wrong := client.NewClient(srv.AppID, "wrong", client.WithBaseURL(srv.URL), client.WithHTTPClient(srv.Client()))
+	if _, err := wrong.GetAccessToken(ctx); !errors.Is(err, client.ErrInvalidCredentials) {
+		t.Errorf("GetAccessToken with a wrong secret = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.BanggoodClient()
+	ctx := context.Background()
+	token := srv.IssueToken()
+
+	srv.InjectFault(client.EndpointGetCountries, Fault{Code: client.CodeRateLimited, Times: 2})
+	srv.InjectFault(client.EndpointGetCountries, Fault{Code: client.CodeInvalidParameter, HTTPStatus: http.StatusBadRequest})
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetCountries(ctx, token); !errors.Is(err, client.ErrRateLimited) {
+			t.Errorf("request %d = %v, want ErrRateLimited", i, err)
+		}
+	}
+	_, err := c.GetCountries(ctx, token)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != client.CodeInvalidParameter || apiErr.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("third request = %v, want the second fault", err)
+	}
+	if _, err := c.GetCountries(ctx, token); err != nil {
+		t.Errorf("request after the faults = %v", err)
+	}
+	if n := srv.Requests(client.EndpointGetCountries); n != 4 {
+		t.Errorf("Requests = %d, want 4", n)
+	}
+	if n := srv.Requests(client.EndpointGetStocks); n != 0 {
+		t.Errorf("Requests of an unused endpoint = %d", n)
+	}
+}
+
+func TestServerPages(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.PageSize = 4
+	srv.Seed(GenerateCatalog(1, 5, 2))
+	c := srv.BanggoodClient()
+	ctx := context.Background()
+	token := srv.IssueToken()
+	page := func(n int) *int { return &n }
+
+	res, err := c.GetProductList(ctx, token, "", nil, nil, nil, nil, page(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PageTotal != 3 || res.ProductTotal != 10 || len(res.ProductList) != 2 || res.ProductList[0].ProductID != "1000008" {
+		t.Errorf("page 3 = %+v", res)
+	}
+	if res, err := c.GetProductList(ctx, token, "", nil, nil, nil, nil, page(9)); err != nil || len(res.ProductList) != 0 {
+		t.Errorf("page past the end = %d products, %v", len(res.ProductList), err)
+	}
+	if _, err := c.GetProductList(ctx, token, "", nil, nil, nil, nil, page(-1)); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("negative page = %v, want ErrBadParameter", err)
+	}
+}
+
+func TestServerProductUpdates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	catalog := GenerateCatalog(1, 2, 2)
+	srv.Seed(catalog)
+	c := srv.BanggoodClient()
+	ctx := context.Background()
+	token := srv.IssueToken()
+
+	srv.Advance(time.Hour)
+	p := catalog.Products[1]
+	p.ProductName = "Renamed"
+	srv.PutProduct(p)
+	srv.SetProductState(catalog.Products[2].ProductID, StateOffShelf)
+	srv.SetProductState(catalog.Products[3].ProductID, StateDeleted)
+
+	updates, err := c.GetAllProductUpdates(ctx, token, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]int{}
+	for _, u := range updates {
+		states[u.ProductID] = u.State
+	}
+	want := map[string]int{
+		catalog.Products[1].ProductID: StateUpdated,
+		catalog.Products[2].ProductID: StateOffShelf,
+		catalog.Products[3].ProductID: StateDeleted,
+	}
+	if len(states) != len(want) {
+		t.Errorf("updates = %+v", updates)
+	}
+	for id, state := range want {
+		if states[id] != state {
+			t.Errorf("product %s state = %d, want %d", id, states[id], state)
+		}
+	}
+
+	products, err := c.GetAllProducts(ctx, token, "", nil, nil, nil, nil)
+	if err != nil || len(products) != 2 || products[1].ProductName != "Renamed" {
+		t.Errorf("listing = %+v, %v; want off-shelf and deleted products hidden", products, err)
+	}
+	if _, err := c.GetStock(ctx, token, catalog.Products[3].ProductID); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetStock of a deleted product = %v", err)
+	}
+}
+
+func TestServerCopiesProducts(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	catalog := GenerateCatalog(1, 1, 1)
+	srv.Seed(catalog)
+	c := srv.ManagedClient()
+	ctx := context.Background()
+	p := catalog.Products[0]
+	stock := p.Stocks[0].StocksList[0]
+
+	srv.SetStock(p.ProductID, p.Stocks[0].Warehouse, stock.PoaID, 999)
+	if got := catalog.Products[0].Stocks[0].StocksList[0].Stock; got != stock.Stock {
+		t.Errorf("SetStock changed the seeded catalog to %s", got)
+	}
+	p.Stocks[0].StocksList[0].Stock = "0"
+	p.Info.WarehouseList[0].Warehouse = "XX"
+	res, err := c.GetStock(ctx, p.ProductID)
+	if err != nil || res.Stocks[0].StocksList[0].Stock != "999" {
+		t.Errorf("GetStock after changing the seeded catalog = %+v, %v", res.Stocks, err)
+	}
+	info, err := c.GetProductInfo(ctx, p.ProductID, nil)
+	if err != nil || info.WarehouseList[0].Warehouse == "XX" {
+		t.Errorf("GetProductInfo after changing the seeded catalog = %+v, %v", info.WarehouseList, err)
+	}
+}
+
+func TestServerProductPrice(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	catalog := GenerateCatalog(1, 1, 1)
+	srv.Seed(catalog)
+	c := srv.ManagedClient()
+	ctx := context.Background()
+	p := catalog.Products[0]
+	w := p.Info.WarehouseList[0]
+	value := p.Info.PoaList[0].OptionValues[1]
+
+	res, err := c.GetProductPrice(ctx, p.ProductID, value.PoaID, w.Warehouse, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := w.WarehousePrice.MustAdd(value.PoaPrice); res.ProductPrice.MustCmp(want) != 0 || res.Currency != want.Currency {
+		t.Errorf("GetProductPrice = %s %s, want %s", res.Currency, res.ProductPrice, want)
+	}
+	if _, err := c.GetProductPrice(ctx, p.ProductID, "1", w.Warehouse, ""); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetProductPrice of an unknown POA = %v, want ErrBadParameter", err)
+	}
+	if _, err := c.GetProductPrice(ctx, p.ProductID, "", "XX", ""); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetProductPrice from an unknown warehouse = %v, want ErrBadParameter", err)
+	}
+}
+
+func TestServerOrders(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	catalog := GenerateCatalog(2, 1, 2)
+	srv.Seed(catalog)
+	c := srv.ManagedClient()
+	ctx := context.Background()
+	p := catalog.Products[0]
+	warehouse, poaID := p.Stocks[0].Warehouse, p.Stocks[0].StocksList[0].PoaID
+	srv.SetStock(p.ProductID, warehouse, poaID, 3)
+	srv.SetProductState(catalog.Products[1].ProductID, StateOffShelf)
+
+	line := client.ImportOrderProduct{ProductID: p.ProductID, Warehouse: warehouse, PoaID: strconv.Itoa(poaID), Quantity: "2", ShipmethodCode: "airmail"}
+	order := client.ImportOrderRequest{
+		AccessToken:           srv.IssueToken(),
+		SaleRecordID:          "SR-1",
+		Currency:              "USD",
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          3,
+		ProductList: []client.ImportOrderProduct{
+			line,
+			line,
+			{ProductID: catalog.Products[1].ProductID, Quantity: "1", ShipmethodCode: "airmail"},
+		},
+	}
+	res, err := c.ImportOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SuccessTotal != "1" || len(res.FailureList) != 2 ||
+		res.FailureList[0].ErrorDescription != "out of stock" || res.FailureList[1].ErrorDescription != "product is not available" {
+		t.Errorf("ImportOrder = %+v", res)
+	}
+	stock, err := c.GetStock(ctx, p.ProductID)
+	if err != nil || stock.Stocks[0].StocksList[0].Stock != "1" {
+		t.Errorf("stock after the order = %+v, %v", stock.Stocks, err)
+	}
+
+	ids := srv.OrderIDs("SR-1")
+	if len(ids) != 1 {
+		t.Fatalf("OrderIDs = %v", ids)
+	}
+	srv.SetOrderStatus(ids[0], StatusShipped)
+	srv.AddTrackEvent(ids[0], "LP123", "Departed")
+
+	info, err := c.GetOrderInfo(ctx, "SR-1")
+	if err != nil || len(info.SaleRecordIDList) != 1 {
+		t.Fatalf("GetOrderInfo = %+v, %v", info, err)
+	}
+	o := info.SaleRecordIDList[0].OrderList[0]
+	if o.OrderID != ids[0] || o.Status != StatusShipped || o.TotalAmount.MustCmp(o.SubAmount.MustAdd(o.Shipfee)) != 0 {
+		t.Errorf("order = %+v", o)
+	}
+	if info.SaleRecordIDList[0].UserInfo[0].DeliveryCity != "Berlin" {
+		t.Errorf("user info = %+v", info.SaleRecordIDList[0].UserInfo)
+	}
+	history, err := c.GetOrderHistory(ctx, "SR-1", ids[0])
+	if err != nil || len(history.OrderHistory) != 2 || history.OrderHistory[1].Status != StatusShipped || history.TrackNumber != "LP123" {
+		t.Errorf("GetOrderHistory = %+v, %v", history, err)
+	}
+	track, err := c.GetTrackInfo(ctx, ids[0])
+	if err != nil || len(track.TrackInfo) != 1 || track.TrackInfo[0].Event != "Departed" {
+		t.Errorf("GetTrackInfo = %+v, %v", track, err)
+	}
+
+	if info, err := c.GetOrderInfo(ctx, "SR-unknown"); err != nil || len(info.SaleRecordIDList) != 0 {
+		t.Errorf("GetOrderInfo of an unknown sale record = %+v, %v", info, err)
+	}
+	if _, err := c.GetTrackInfo(ctx, "1"); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetTrackInfo of an unknown order = %v", err)
+	}
+}
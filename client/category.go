@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrCategoryCycle = errors.New("banggood: category parents form a cycle")
+
+// CategoryTree is the category hierarchy built from a flat category list.
+// Categories whose parent is missing from the list are kept as roots and
+// reported by Orphans.
+type CategoryTree struct {
+	categories map[string]Category
+	children   map[string][]string
+	roots      []string
+	orphans    []string
+}
+
+func isRootCategory(c Category) bool {
+	return c.ParentID == "" || c.ParentID == "0"
+}
+
+// NewCategoryTree builds a tree from categories, keeping their order among
+// siblings. It fails with ErrCategoryCycle if a category is its own
+// ancestor.
+func NewCategoryTree(categories []Category) (*CategoryTree, error) {
+	t := &CategoryTree{
+		categories: make(map[string]Category, len(categories)),
+		children:   map[string][]string{},
+	}
+	var order []string
+	for _, c := range categories {
+		if _, ok := t.categories[c.CategoryID]; !ok {
+			order = append(order, c.CategoryID)
+		}
+		t.categories[c.CategoryID] = c
+	}
+	for _, id := range order {
+		c := t.categories[id]
+		switch _, ok := t.categories[c.ParentID]; {
+		case isRootCategory(c):
+			t.roots = append(t.roots, id)
+		case !ok:
+			t.roots = append(t.roots, id)
+			t.orphans = append(t.orphans, id)
+		default:
+			t.children[c.ParentID] = append(t.children[c.ParentID], id)
+		}
+	}
+
+	reached := map[string]bool{}
+	for _, id := range t.roots {
+		t.walk(id, 0, func(c Category, depth int) error {
+			reached[c.CategoryID] = true
+			return nil
+		})
+	}
+	if len(reached) < len(order) {
+		var cycle []string
+		for _, id := range order {
+			if !reached[id] {
+				cycle = append(cycle, id)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("%w: %s", ErrCategoryCycle, strings.Join(cycle, ", "))
+	}
+	return t, nil
+}
+
+func (t *CategoryTree) list(ids []string) []Category {
+	categories := make([]Category, len(ids))
+	for i, id := range ids {
+		categories[i] = t.categories[id]
+	}
+	return categories
+}
+
+func (t *CategoryTree) Len() int {
+	return len(t.categories)
+}
+
+func (t *CategoryTree) Category(id string) (Category, bool) {
+	c, ok := t.categories[id]
+	return c, ok
+}
+
+// Roots returns the top-level categories, including orphans.
+func (t *CategoryTree) Roots() []Category {
+	return t.list(t.roots)
+}
+
+// Orphans returns the categories whose parent is not in the tree.
+func (t *CategoryTree) Orphans() []Category {
+	return t.list(t.orphans)
+}
+
+func (t *CategoryTree) Children(id string) []Category {
+	return t.list(t.children[id])
+}
+
+func (t *CategoryTree) Parent(id string) (Category, bool) {
+	c, ok := t.categories[id]
+	if !ok {
+		return Category{}, false
+	}
+	return t.Category(c.ParentID)
+}
+
+// Ancestors returns the ancestors of id, root first.
+func (t *CategoryTree) Ancestors(id string) []Category {
+	path := t.Path(id)
+	if len(path) == 0 {
+		return nil
+	}
+	return path[:len(path)-1]
+}
+
+// Path returns the categories from the root down to id, or nil if id is not
+// in the tree.
+func (t *CategoryTree) Path(id string) []Category {
+	var path []Category
+	for c, ok := t.categories[id]; ok; c, ok = t.Parent(c.CategoryID) {
+		path = append(path, c)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Breadcrumb joins the names along Path(id) with " > ".
+func (t *CategoryTree) Breadcrumb(id string) string {
+	path := t.Path(id)
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.CategoryName
+	}
+	return strings.Join(names, " > ")
+}
+
+// Depth returns the number of ancestors of id, or -1 if id is not in the
+// tree.
+func (t *CategoryTree) Depth(id string) int {
+	return len(t.Path(id)) - 1
+}
+
+// Walk calls fn for every category in depth-first order, parents before
+// children. Depth is relative to the roots. Walk stops at the first error
+// fn returns.
+func (t *CategoryTree) Walk(fn func(c Category, depth int) error) error {
+	for _, id := range t.roots {
+		if err := t.walk(id, 0, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkSubtree is like Walk but starts at id, which is visited at depth 0.
+func (t *CategoryTree) WalkSubtree(id string, fn func(c Category, depth int) error) error {
+	if _, ok := t.categories[id]; !ok {
+		return nil
+	}
+	return t.walk(id, 0, fn)
+}
+
+func (t *CategoryTree) walk(id string, depth int, fn func(c Category, depth int) error) error {
+	if err := fn(t.categories[id], depth); err != nil {
+		return err
+	}
+	for _, child := range t.children[id] {
+		if err := t.walk(child, depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subtree returns id and all its descendants in Walk order.
+func (t *CategoryTree) Subtree(id string) []Category {
+	var categories []Category
+	_ = t.WalkSubtree(id, func(c Category, depth int) error {
+		categories = append(categories, c)
+		return nil
+	})
+	return categories
+}
+
+// SubtreeProducts lists the products of id and all its descendants through
+// c, skipping products listed under more than one category.
+func (t *CategoryTree) SubtreeProducts(ctx context.Context, c ManagedClient, id string) ([]Product, error) {
+	var products []Product
+	seen := map[string]bool{}
+	err := t.WalkSubtree(id, func(category Category, depth int) error {
+		it := c.Products(ctx, category.CategoryID, nil, nil, nil, nil)
+		for {
+			p, err := it.Next()
+			if err == Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !seen[p.ProductID] {
+				seen[p.ProductID] = true
+				products = append(products, p)
+			}
+		}
+	})
+	return products, err
+}
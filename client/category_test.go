@@ -0,0 +1,139 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func categoryIDs(categories []client.Category) []string {
+	ids := make([]string, len(categories))
+	for i, c := range categories {
+		ids[i] = c.CategoryID
+	}
+	return ids
+}
+
+// categoryCatalog is Electronics > Phones > Cases, Electronics > Audio,
+// Garden, and Lost, whose parent is not listed.
+func categoryCatalog() banggoodtest.Catalog {
+	product := func(id string, category int) banggoodtest.Product {
+		return banggoodtest.Product{Product: client.Product{ProductID: id, CategoryID: category, ProductName: "Product " + id}}
+	}
+	return banggoodtest.Catalog{
+		Categories: []client.Category{
+			{CategoryID: "1", CategoryName: "Electronics", ParentID: "0"},
+			{CategoryID: "2", CategoryName: "Phones", ParentID: "1"},
+			{CategoryID: "3", CategoryName: "Cases", ParentID: "2"},
+			{CategoryID: "4", CategoryName: "Audio", ParentID: "1"},
+			{CategoryID: "5", CategoryName: "Garden", ParentID: ""},
+			{CategoryID: "6", CategoryName: "Lost", ParentID: "99"},
+		},
+		Products: []banggoodtest.Product{
+			product("100", 1), product("200", 2), product("300", 3), product("400", 4), product("500", 5),
+		},
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 4
+	srv.Seed(categoryCatalog())
+	tree, err := srv.ManagedClient().GetCategoryTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Len() != 6 {
+		t.Errorf("Len = %d", tree.Len())
+	}
+	if got := categoryIDs(tree.Roots()); !reflect.DeepEqual(got, []string{"1", "5", "6"}) {
+		t.Errorf("Roots = %v", got)
+	}
+	if got := categoryIDs(tree.Orphans()); !reflect.DeepEqual(got, []string{"6"}) {
+		t.Errorf("Orphans = %v", got)
+	}
+	if got := categoryIDs(tree.Children("1")); !reflect.DeepEqual(got, []string{"2", "4"}) {
+		t.Errorf("Children(1) = %v", got)
+	}
+	if got := categoryIDs(tree.Ancestors("3")); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("Ancestors(3) = %v", got)
+	}
+	if got := tree.Breadcrumb("3"); got != "Electronics > Phones > Cases" {
+		t.Errorf("Breadcrumb(3) = %q", got)
+	}
+	if tree.Depth("3") != 2 || tree.Depth("6") != 0 || tree.Depth("missing") != -1 {
+		t.Errorf("Depth = %d, %d, %d", tree.Depth("3"), tree.Depth("6"), tree.Depth("missing"))
+	}
+	if got := categoryIDs(tree.Subtree("1")); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Subtree(1) = %v", got)
+	}
+	if tree.Subtree("missing") != nil {
+		t.Error("Subtree of a missing category is not empty")
+	}
+
+	var depths []int
+	tree.Walk(func(c client.Category, depth int) error {
+		depths = append(depths, depth)
+		return nil
+	})
+	if !reflect.DeepEqual(depths, []int{0, 1, 2, 1, 0, 0}) {
+		t.Errorf("Walk depths = %v", depths)
+	}
+	stop := errors.New("stop")
+	visited := 0
+	err = tree.Walk(func(c client.Category, depth int) error {
+		visited++
+		return stop
+	})
+	if err != stop || visited != 1 {
+		t.Errorf("Walk = %v after %d categories, want to stop at the first", err, visited)
+	}
+}
+
+func TestCategoryTreeCycle(t *testing.T) {
+	_, err := client.NewCategoryTree([]client.Category{
+		{CategoryID: "1", ParentID: "0"},
+		{CategoryID: "2", ParentID: "3"},
+		{CategoryID: "3", ParentID: "2"},
+		{CategoryID: "4", ParentID: "4"},
+	})
+	if !errors.Is(err, client.ErrCategoryCycle) || err.Error() != "banggood: category parents form a cycle: 2, 3, 4" {
+		t.Errorf("NewCategoryTree = %v, want a cycle through 2, 3 and 4", err)
+	}
+}
+
+func TestSubtreeProducts(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 1
+	srv.Seed(categoryCatalog())
+	c := srv.ManagedClient()
+	ctx := context.Background()
+	tree, err := c.GetCategoryTree(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	products, err := tree.SubtreeProducts(ctx, c, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range products {
+		ids = append(ids, p.ProductID)
+	}
+	if !reflect.DeepEqual(ids, []string{"200", "300"}) {
+		t.Errorf("SubtreeProducts(2) = %v", ids)
+	}
+
+	srv.InjectFault(client.EndpointGetProductList, banggoodtest.Fault{Code: client.CodeInvalidParameter})
+	if _, err := tree.SubtreeProducts(ctx, c, "1"); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("SubtreeProducts with a failing page = %v", err)
+	}
+}
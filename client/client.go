@@ -2,18 +2,36 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"time"
 )
 
 const (
-	defaultURL     = "https://api.banggood.com"
-	defaultTestURL = "https://apibeta.banggood.com&apiTest=1&"
-	pageFrom       = 1
+	defaultURL = "https://api.banggood.com"
+	sandboxURL = "https://apibeta.banggood.com"
+	pageFrom   = 1
+)
+
+const (
+	EndpointTranslate              = "product/Translate"
+	EndpointGetProductPrice        = "product/GetProductPrice"
+	EndpointGetAccessToken         = "getAccessToken"
+	EndpointGetCategoryList        = "category/getCategoryList"
+	EndpointGetProductList         = "product/getProductList"
+	EndpointGetProductInfo         = "product/getProductInfo"
+	EndpointGetShipments           = "product/getShipments"
+	EndpointImportOrder            = "importOrder"
+	EndpointGetOrderInfo           = "order/getOrderInfo"
+	EndpointGetTrackInfo           = "getTrackInfo"
+	EndpointGetOrderHistory        = "getOrderHistory"
+	EndpointGetCountries           = "common/getCountries"
+	EndpointGetStocks              = "product/getStocks"
+	EndpointGetProductUpdateList   = "product/getProductUpdateList"
+	EndpointGetLimitPriceBrand     = "product/getLimitPriceBrand"
+	EndpointGetBrandLimitPriceList = "product/getBrandLimitPriceList"
 )
 
 type BanggoodClient interface {
@@ -21,29 +39,59 @@ type BanggoodClient interface {
 	GetProductPrice(ctx context.Context, token, productID, poaID, warehouse, currency string) (GetProductPriceResponse, error)
 	GetAccessToken(ctx context.Context) (GetAccessTokenResponse, error)
 	GetCategoryList(ctx context.Context, token string, page *int) (GetCategoryListResponse, error)
-	GetAllCategories(token string) ([]Category, error)
+	GetAllCategories(ctx context.Context, token string) ([]Category, error)
+	GetCategoryTree(ctx context.Context, token string) (*CategoryTree, error)
+	Categories(ctx context.Context, token string) *CategoryIterator
 	GetProductList(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) (GetProductListResponse, error)
-	GetAllProducts(token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error)
+	GetAllProducts(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error)
+	Products(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) *ProductIterator
 	GetProductInfo(ctx context.Context, token, productID string, currency *string) (GetProductInfoResponse, error)
 	GetShipments(ctx context.Context, token, productID, warehouse, country, poaID, currency string, quantity int) (GetShipmentsResponse, error)
-	ImportOrder(ctx context.Context) (ImportOrderResponse, error)
+	ImportOrder(ctx context.Context, order ImportOrderRequest) (ImportOrderResponse, error)
 	GetOrderInfo(ctx context.Context, token, saleRecordID string) (GetOrderInfoResponse, error)
 	GetTrackInfo(ctx context.Context, token, orderID string) (GetTrackInfoResponse, error)
 	GetOrderHistory(ctx context.Context, token, saleRecordID, orderID string) (GetOrderHistoryResponse, error)
 	GetCountries(ctx context.Context, token string) (GetCountriesResponse, error)
 	GetStock(ctx context.Context, token, productID string) (GetStockResponse, error)
+	GetVariantMatrix(ctx context.Context, token, productID string, currency *string) (VariantMatrix, error)
 	GetProductUpdateList(ctx context.Context, token string, minutes, page int) (GetProductUpdateListResponse, error)
+	GetAllProductUpdates(ctx context.Context, token string, minutes int) ([]ProductUpdate, error)
+	ProductUpdates(ctx context.Context, token string, minutes int) *ProductUpdateIterator
 	GetLimitPriceBrand(ctx context.Context, token string, page int) (GetLimitPriceBrandResponse, error)
+	GetAllLimitPriceBrands(ctx context.Context, token string) ([]LimitPriceBrand, error)
+	LimitPriceBrands(ctx context.Context, token string) *LimitPriceBrandIterator
 	GetBrandLimitPriceList(ctx context.Context, token, brandID string, page int) (GetBrandLimitPriceListResponse, error)
+	GetAllBrandLimitPrices(ctx context.Context, token, brandID string) ([]BrandLimitPrice, error)
+	BrandLimitPrices(ctx context.Context, token, brandID string) *BrandLimitPriceIterator
+	IsSandbox() bool
 }
 
 func NewDefaultClient(id, secret string) BanggoodClient {
-	return client{
+	return NewClient(id, secret)
+}
+
+// NewSandboxClient returns a client for the Banggood beta environment.
+// Every request it sends carries apiTest=1.
+func NewSandboxClient(id, secret string) BanggoodClient {
+	return NewClient(id, secret, WithSandbox())
+}
+
+func NewClient(id, secret string, opts ...Option) BanggoodClient {
+	c := client{
 		AppID:      id,
 		AppSecret:  secret,
 		HTTPClient: http.DefaultClient,
 		BaseURL:    defaultURL,
+		Language:   en,
+		Retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(&c)
 	}
+	if c.Sandbox && c.BaseURL == defaultURL {
+		c.BaseURL = sandboxURL
+	}
+	return c
 }
 
 type client struct {
@@ -51,244 +99,300 @@ type client struct {
 	AppSecret  string
 	BaseURL    string
 	HTTPClient *http.Client
+	Sandbox    bool
+	Language   string
+	Currency   string
+	UserAgent  string
+	Logger     Logger
+	Retry      RetryPolicy
+	Limiter    *rateLimiter
 }
 
-func (c client) do(req *http.Request) (*http.Response, error) {
-	return c.HTTPClient.Do(req)
+func (c client) IsSandbox() bool {
+	return c.Sandbox
 }
-func (c client) Translate(ctx context.Context, token, productID, poaID, warehouse, currency string) (TranslateResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.translateURL(token, productID, poaID, warehouse, currency), nil)
-	if err != nil {
-		return TranslateResponse{}, err
+
+func (c client) do(req *http.Request) (*http.Response, error) {
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
 	}
-	res, err := c.do(req)
-	if err != nil {
-		return TranslateResponse{}, err
+	start := time.Now()
+	res, err := c.HTTPClient.Do(req)
+	if c.Logger != nil {
+		if err != nil {
+			c.Logger.Printf("banggood: %s %s: %v", req.Method, redactURL(req.URL), err)
+		} else {
+			c.Logger.Printf("banggood: %s %s: %d in %s", req.Method, redactURL(req.URL), res.StatusCode, time.Since(start))
+		}
 	}
-	var data TranslateResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	return res, err
 }
 
-func (c client) GetProductPrice(ctx context.Context, token, productID, poaID, warehouse, currency string) (GetProductPriceResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getProductPriceURL(token, productID, poaID, warehouse, currency), nil)
-	if err != nil {
-		return GetProductPriceResponse{}, err
+// currency returns value, or the client's default currency when value is empty.
+func (c client) currency(value string) string {
+	if value == "" {
+		return c.Currency
 	}
-	res, err := c.do(req)
+	return value
+}
+
+func (c client) get(ctx context.Context, endpoint, url string, data interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return GetProductPriceResponse{}, err
+		return err
 	}
-	var data GetProductPriceResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	return c.send(endpoint, req, data)
 }
 
-func (c client) GetAccessToken(ctx context.Context) (GetAccessTokenResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getAccessTokenURL(), nil)
-	if err != nil {
-		return GetAccessTokenResponse{}, err
+// send performs req, retrying according to c.Retry when the request is
+// idempotent.
+func (c client) send(endpoint string, req *http.Request, data interface{}) error {
+	attempts := 1
+	if idempotent(req) {
+		attempts = c.Retry.attempts()
 	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetAccessTokenResponse{}, err
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = c.sendOnce(endpoint, req, data)
+		if err == nil || attempt >= attempts || !c.Retry.retryable(err) {
+			return err
+		}
+		delay := c.Retry.backoff(attempt)
+		if c.Logger != nil {
+			c.Logger.Printf("banggood: %s: attempt %d failed, retrying in %s: %v", endpoint, attempt, delay, err)
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return err
+		}
+		if req, err = rewind(req); err != nil {
+			return err
+		}
+		reset(data)
 	}
-	var data GetAccessTokenResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
 }
 
-func (c client) GetCategoryList(ctx context.Context, token string, page *int) (GetCategoryListResponse, error) {
-	// fmt.Println("URL: ", c.getCategoryListURL(token, page))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getCategoryListURL(token, page), nil)
-	if err != nil {
-		return GetCategoryListResponse{}, err
+func (c client) sendOnce(endpoint string, req *http.Request, data interface{}) error {
+	limit := c.Limiter.bucket(endpoint)
+	if err := limit.wait(req.Context()); err != nil {
+		return err
 	}
+
 	res, err := c.do(req)
 	if err != nil {
-		return GetCategoryListResponse{}, err
+		return err
 	}
-	var data GetCategoryListResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	err = decodeResponse(endpoint, res.StatusCode, body, data)
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		limit.throttled()
+	case err == nil:
+		limit.succeeded()
+	}
+	return err
 }
 
-func (c client) GetAllCategories(token string) ([]Category, error) {
-	var categories []Category
-	page := pageFrom
-	for {
-		res, err := c.GetCategoryList(context.Background(), token, &page)
+// reset zeroes the value data points to, so a retried response is not
+// merged into what a failed attempt decoded.
+func reset(data interface{}) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// rewind returns a copy of req with a fresh body so it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
 		if err != nil {
 			return nil, err
 		}
-		categories = append(categories, res.CategoryList...)
-		if res.PageNumber == res.PageTotal {
-			break
-		}
-		page++
+		clone.Body = body
 	}
-	return categories, nil
+	return clone, nil
 }
 
-func (c client) GetProductList(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) (GetProductListResponse, error) {
-	// fmt.Println("URL: ", c.getProductListURL(token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, page))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getProductListURL(token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, page), nil)
-	if err != nil {
-		return GetProductListResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetProductListResponse{}, err
+func (c client) Translate(ctx context.Context, token, productID, poaID, warehouse, currency string) (TranslateResponse, error) {
+	var data TranslateResponse
+	err := c.get(ctx, EndpointTranslate, c.translateURL(token, productID, poaID, warehouse, currency), &data)
+	return data, err
+}
+
+func (c client) GetProductPrice(ctx context.Context, token, productID, poaID, warehouse, currency string) (GetProductPriceResponse, error) {
+	var data GetProductPriceResponse
+	err := c.get(ctx, EndpointGetProductPrice, c.getProductPriceURL(token, productID, poaID, warehouse, currency), &data)
+	if data.Currency == "" {
+		data.Currency = c.currency(currency)
 	}
+	data.ProductPrice.Currency = data.Currency
+	return data, err
+}
+
+func (c client) GetAccessToken(ctx context.Context) (GetAccessTokenResponse, error) {
+	var data GetAccessTokenResponse
+	err := c.get(ctx, EndpointGetAccessToken, c.getAccessTokenURL(), &data)
+	return data, err
+}
+
+func (c client) GetCategoryList(ctx context.Context, token string, page *int) (GetCategoryListResponse, error) {
+	var data GetCategoryListResponse
+	err := c.get(ctx, EndpointGetCategoryList, c.getCategoryListURL(token, page), &data)
+	return data, err
+}
+
+func (c client) GetAllCategories(ctx context.Context, token string) ([]Category, error) {
+	return c.Categories(ctx, token).All()
+}
+
+func (c client) Categories(ctx context.Context, token string) *CategoryIterator {
+	return newCategoryIterator(ctx, func(ctx context.Context, page int) (GetCategoryListResponse, error) {
+		return c.GetCategoryList(ctx, token, &page)
+	})
+}
+
+func (c client) GetProductList(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) (GetProductListResponse, error) {
 	var data GetProductListResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetProductList, c.getProductListURL(token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, page), &data)
+	return data, err
 }
 
-func (c client) GetAllProducts(token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error) {
-	var products []Product
-	page := pageFrom
-	for {
-		res, err := c.GetProductList(context.Background(), token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, &page)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, res.ProductList...)
-		if res.PageNumber == res.PageTotal {
-			break
-		}
-		page++
-	}
-	return products, nil
+func (c client) GetAllProducts(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error) {
+	return c.Products(ctx, token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd).All()
+}
+
+func (c client) Products(ctx context.Context, token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) *ProductIterator {
+	return newProductIterator(ctx, func(ctx context.Context, page int) (GetProductListResponse, error) {
+		return c.GetProductList(ctx, token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, &page)
+	})
 }
 
 func (c client) GetProductInfo(ctx context.Context, token, productID string, currency *string) (GetProductInfoResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getProductInfoURL(token, productID, currency), nil)
-	if err != nil {
-		return GetProductInfoResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetProductInfoResponse{}, err
-	}
-	bytes, _ := ioutil.ReadAll(res.Body)
-	fmt.Println("Response: ", string(bytes))
 	var data GetProductInfoResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetProductInfo, c.getProductInfoURL(token, productID, currency), &data)
+	if currency == nil {
+		currency = &c.Currency
+	}
+	data.setCurrency(*currency)
+	return data, err
 }
 
 func (c client) GetShipments(ctx context.Context, token, productID, warehouse, country, poaID, currency string, quantity int) (GetShipmentsResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getShipmentsURL(token, productID, warehouse, country, poaID, currency, quantity), nil)
-	if err != nil {
-		return GetShipmentsResponse{}, err
+	var data GetShipmentsResponse
+	err := c.get(ctx, EndpointGetShipments, c.getShipmentsURL(token, productID, warehouse, country, poaID, currency, quantity), &data)
+	if data.Currency == "" {
+		data.Currency = c.currency(currency)
 	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetShipmentsResponse{}, err
+	for i := range data.ShipMethods {
+		data.ShipMethods[i].Shipfee.Currency = data.Currency
 	}
-	var data GetShipmentsResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
-}
-
-func (c client) ImportOrder(ctx context.Context) (ImportOrderResponse, error) {
-	return ImportOrderResponse{}, errors.New("unimplemented")
+	return data, err
 }
 
 func (c client) GetOrderInfo(ctx context.Context, token, saleRecordID string) (GetOrderInfoResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getOrderInfoURL(token, saleRecordID), nil)
-	if err != nil {
-		return GetOrderInfoResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetOrderInfoResponse{}, err
-	}
 	var data GetOrderInfoResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetOrderInfo, c.getOrderInfoURL(token, saleRecordID), &data)
+	data.setCurrency()
+	return data, err
 }
 
 func (c client) GetTrackInfo(ctx context.Context, token, orderID string) (GetTrackInfoResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getTrackInfoURL(token, orderID), nil)
-	if err != nil {
-		return GetTrackInfoResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetTrackInfoResponse{}, err
-	}
 	var data GetTrackInfoResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetTrackInfo, c.getTrackInfoURL(token, orderID), &data)
+	return data, err
 }
 
 func (c client) GetOrderHistory(ctx context.Context, token, saleRecordID, orderID string) (GetOrderHistoryResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getOrderHistoryURL(token, saleRecordID, orderID), nil)
-	if err != nil {
-		return GetOrderHistoryResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetOrderHistoryResponse{}, err
-	}
 	var data GetOrderHistoryResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetOrderHistory, c.getOrderHistoryURL(token, saleRecordID, orderID), &data)
+	return data, err
 }
 
-func (c client) GetCountries(ctx context.Context, token string) (GetCountriesResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getCountriesURL(token), nil)
+func (c client) GetCategoryTree(ctx context.Context, token string) (*CategoryTree, error) {
+	categories, err := c.GetAllCategories(ctx, token)
 	if err != nil {
-		return GetCountriesResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetCountriesResponse{}, err
+		return nil, err
 	}
+	return NewCategoryTree(categories)
+}
+
+func (c client) GetCountries(ctx context.Context, token string) (GetCountriesResponse, error) {
 	var data GetCountriesResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetCountries, c.getCountriesURL(token), &data)
+	return data, err
 }
 
 func (c client) GetStock(ctx context.Context, token, productID string) (GetStockResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getStockURL(token, productID), nil)
-	if err != nil {
-		return GetStockResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetStockResponse{}, err
-	}
 	var data GetStockResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetStocks, c.getStockURL(token, productID), &data)
+	return data, err
 }
 
-func (c client) GetProductUpdateList(ctx context.Context, token string, minutes, page int) (GetProductUpdateListResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getProductUpdateListURL(token, minutes, page), nil)
+// GetVariantMatrix fetches the info and stock of a product and expands them
+// into variants.
+func (c client) GetVariantMatrix(ctx context.Context, token, productID string, currency *string) (VariantMatrix, error) {
+	info, err := c.GetProductInfo(ctx, token, productID, currency)
 	if err != nil {
-		return GetProductUpdateListResponse{}, err
+		return VariantMatrix{}, err
 	}
-	res, err := c.do(req)
+	stock, err := c.GetStock(ctx, token, productID)
 	if err != nil {
-		return GetProductUpdateListResponse{}, err
+		return VariantMatrix{}, err
 	}
+	return NewVariantMatrix(productID, info, stock)
+}
+
+func (c client) GetProductUpdateList(ctx context.Context, token string, minutes, page int) (GetProductUpdateListResponse, error) {
 	var data GetProductUpdateListResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetProductUpdateList, c.getProductUpdateListURL(token, minutes, page), &data)
+	return data, err
+}
+
+func (c client) GetAllProductUpdates(ctx context.Context, token string, minutes int) ([]ProductUpdate, error) {
+	return c.ProductUpdates(ctx, token, minutes).All()
+}
+
+func (c client) ProductUpdates(ctx context.Context, token string, minutes int) *ProductUpdateIterator {
+	return newProductUpdateIterator(ctx, func(ctx context.Context, page int) (GetProductUpdateListResponse, error) {
+		return c.GetProductUpdateList(ctx, token, minutes, page)
+	})
 }
 
 func (c client) GetLimitPriceBrand(ctx context.Context, token string, page int) (GetLimitPriceBrandResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getLimitPriceBrandURL(token, page), nil)
-	if err != nil {
-		return GetLimitPriceBrandResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetLimitPriceBrandResponse{}, err
-	}
 	var data GetLimitPriceBrandResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetLimitPriceBrand, c.getLimitPriceBrandURL(token, page), &data)
+	return data, err
+}
+
+func (c client) GetAllLimitPriceBrands(ctx context.Context, token string) ([]LimitPriceBrand, error) {
+	return c.LimitPriceBrands(ctx, token).All()
+}
+
+func (c client) LimitPriceBrands(ctx context.Context, token string) *LimitPriceBrandIterator {
+	return newLimitPriceBrandIterator(ctx, func(ctx context.Context, page int) (GetLimitPriceBrandResponse, error) {
+		return c.GetLimitPriceBrand(ctx, token, page)
+	})
 }
 
 func (c client) GetBrandLimitPriceList(ctx context.Context, token, brandID string, page int) (GetBrandLimitPriceListResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.getBrandLimitPriceListURL(token, brandID, page), nil)
-	if err != nil {
-		return GetBrandLimitPriceListResponse{}, err
-	}
-	res, err := c.do(req)
-	if err != nil {
-		return GetBrandLimitPriceListResponse{}, err
-	}
 	var data GetBrandLimitPriceListResponse
-	return data, json.NewDecoder(res.Body).Decode(&data)
+	err := c.get(ctx, EndpointGetBrandLimitPriceList, c.getBrandLimitPriceListURL(token, brandID, page), &data)
+	return data, err
+}
+
+func (c client) GetAllBrandLimitPrices(ctx context.Context, token, brandID string) ([]BrandLimitPrice, error) {
+	return c.BrandLimitPrices(ctx, token, brandID).All()
+}
+
+func (c client) BrandLimitPrices(ctx context.Context, token, brandID string) *BrandLimitPriceIterator {
+	return newBrandLimitPriceIterator(ctx, func(ctx context.Context, page int) (GetBrandLimitPriceListResponse, error) {
+		return c.GetBrandLimitPriceList(ctx, token, brandID, page)
+	})
 }
@@ -0,0 +1,275 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// replayClient serves testdata/responses.json, which pins the payload shapes
+// Banggood is known to send: codes as strings or numbers, amounts as strings,
+// numbers, "" or null, zero dates and the legacy single-method shipments.
+func replayClient(t *testing.T) client.BanggoodClient {
+	t.Helper()
+	rec, err := banggoodtest.NewRecorder("testdata/responses.json", banggoodtest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.NewClient("id", "secret", client.WithHTTPClient(rec.HTTPClient()), client.WithCurrency("USD"))
+}
+
+func wantMoney(t *testing.T, name string, got client.Money, want string) {
+	t.Helper()
+	if got.String() != want {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
+
+func TestDecodeGetAccessToken(t *testing.T) {
+	res, err := replayClient(t).GetAccessToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AccessToken != "REDACTED" || res.ExpiresIn != 7200 {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestDecodeGetCategoryList(t *testing.T) {
+	page := 1
+	res, err := replayClient(t).GetCategoryList(context.Background(), "token", &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CategoryTotal != 2 || res.PageTotal != 1 || res.PageSize != 200 || len(res.CategoryList) != 2 {
+		t.Fatalf("got %+v", res)
+	}
+	if c := res.CategoryList[1]; c.CategoryID != "2515" || c.ParentID != "133" || c.CategoryName != "RC Drones" {
+		t.Errorf("category = %+v", c)
+	}
+}
+
+func TestDecodeGetProductList(t *testing.T) {
+	page := 1
+	res, err := replayClient(t).GetProductList(context.Background(), "token", "2515", nil, nil, nil, nil, &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ProductList) != 1 {
+		t.Fatalf("got %+v", res)
+	}
+	p := res.ProductList[0]
+	if p.ProductID != "1180452" || p.CategoryID != 2515 {
+		t.Errorf("product = %+v", p)
+	}
+	want := time.Date(2017, 6, 1, 2, 20, 30, 0, time.UTC)
+	if !p.AddDate.Equal(want) {
+		t.Errorf("AddDate = %v, want %v", p.AddDate, want)
+	}
+	if !p.ModifyDate.IsZero() {
+		t.Errorf("ModifyDate = %v, want zero", p.ModifyDate)
+	}
+}
+
+func TestDecodeGetProductInfo(t *testing.T) {
+	res, err := replayClient(t).GetProductInfo(context.Background(), "token", "1180452", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ProductName != "Eachine E58 WIFI FPV" || res.Weight != 0.35 || len(res.ImageList) != 1 {
+		t.Errorf("got %+v", res)
+	}
+	if len(res.WarehouseList) != 2 || len(res.PoaList) != 1 || len(res.PoaList[0].OptionValues) != 2 {
+		t.Fatalf("got %+v", res)
+	}
+	wantMoney(t, "CN price", res.WarehouseList[0].WarehousePrice, "USD 39.99")
+	wantMoney(t, "US price", res.WarehouseList[1].WarehousePrice, "USD 42.49")
+	wantMoney(t, "Black price", res.PoaList[0].OptionValues[0].PoaPrice, "USD 0.00")
+	wantMoney(t, "White price", res.PoaList[0].OptionValues[1].PoaPrice, "USD 1.50")
+}
+
+func TestDecodeGetShipments(t *testing.T) {
+	c := replayClient(t)
+	res, err := c.GetShipments(context.Background(), "token", "1180452", "CN", "Germany", "200001", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ShipMethods) != 2 {
+		t.Fatalf("got %+v", res)
+	}
+	m := res.ShipMethods[1]
+	if m.ShipMethodCode != "expressshipping" || m.Shipday != "5-8 business days" {
+		t.Errorf("method = %+v", m)
+	}
+	wantMoney(t, "airmail fee", res.ShipMethods[0].Shipfee, "USD 3.52")
+	wantMoney(t, "express fee", m.Shipfee, "USD 19.30")
+
+	legacy, err := c.GetShipments(context.Background(), "token", "1180452", "US", "Germany", "200001", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy.ShipMethods) != 1 || legacy.ShipMethods[0].ShipMethodCode != "standard" || legacy.ShipMethods[0].Shipday != "10~15 days" {
+		t.Fatalf("legacy = %+v", legacy)
+	}
+	wantMoney(t, "standard fee", legacy.ShipMethods[0].Shipfee, "USD 0.00")
+}
+
+func TestDecodeImportOrder(t *testing.T) {
+	res, err := replayClient(t).ImportOrder(context.Background(), client.ImportOrderRequest{
+		AccessToken:           "token",
+		SaleRecordID:          "SR-1001",
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          1,
+		ProductList: []client.ImportOrderProduct{
+			{ProductID: "1180452", PoaID: "200001", Warehouse: "CN", Quantity: "2", ShipmethodCode: "airmail"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SuccessTotal != "1" || res.FailureTotal != "1" || len(res.FailureList) != 1 {
+		t.Fatalf("got %+v", res)
+	}
+	if f := res.FailureList[0]; f.PoaID != "200002" || f.ErrorDescription != "out of stock" {
+		t.Errorf("failure = %+v", f)
+	}
+}
+
+func TestDecodeGetOrderInfo(t *testing.T) {
+	res, err := replayClient(t).GetOrderInfo(context.Background(), "token", "SR-1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.SaleRecordIDList) != 1 || len(res.SaleRecordIDList[0].OrderList) != 1 {
+		t.Fatalf("got %+v", res)
+	}
+	sr := res.SaleRecordIDList[0]
+	o := sr.OrderList[0]
+	if o.OrderID != "70000001" || o.ShipmethodCode != "airmail" || len(o.ProductList) != 1 || o.ProductList[0].Quantity != 2 {
+		t.Errorf("order = %+v", o)
+	}
+	wantMoney(t, "TotalAmount", o.TotalAmount, "EUR 83.50")
+	wantMoney(t, "SubAmount", o.SubAmount, "EUR 79.98")
+	wantMoney(t, "DropShipDiscount", o.DropShipDiscount, "EUR 0.00")
+	wantMoney(t, "ShipInsurance", o.ShipInsurance, "EUR 0.00")
+	if u := sr.UserInfo[0]; u.DeliveryCity != "Berlin" || u.DeliveryStreetAddress2 != "c/o M & M" {
+		t.Errorf("user info = %+v", u)
+	}
+}
+
+func TestDecodeGetTrackInfo(t *testing.T) {
+	res, err := replayClient(t).GetTrackInfo(context.Background(), "token", "70000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.TrackInfo) != 2 || res.TrackInfo[1].Event != "Delivered" || res.TrackInfo[1].Time != "2021-03-15 14:30:00" {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestDecodeGetOrderHistory(t *testing.T) {
+	res, err := replayClient(t).GetOrderHistory(context.Background(), "token", "SR-1001", "70000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TrackNumber != "LP00123456789" || len(res.OrderHistory) != 2 || res.OrderHistory[1].Status != "Shipped" {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestDecodeGetCountries(t *testing.T) {
+	res, err := replayClient(t).GetCountries(context.Background(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Countries) != 2 || res.Countries[0].CountryID != 81 || res.Countries[0].CountryName != "Germany" {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestDecodeGetStock(t *testing.T) {
+	res, err := replayClient(t).GetStock(context.Background(), "token", "1180452")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Stocks) != 1 || len(res.Stocks[0].StocksList) != 2 {
+		t.Fatalf("got %+v", res)
+	}
+	if s := res.Stocks[0].StocksList[0]; s.PoaID != 200001 || s.Stock != "25" || s.StocksMessage != "In stock" {
+		t.Errorf("stock = %+v", s)
+	}
+}
+
+func TestDecodeGetProductUpdateList(t *testing.T) {
+	res, err := replayClient(t).GetProductUpdateList(context.Background(), "token", 60, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.UpdateProductList) != 2 {
+		t.Fatalf("got %+v", res)
+	}
+	u := res.UpdateProductList[1]
+	if u.ProductID != "1099999" || u.State != client.ProductStateDeleted {
+		t.Errorf("update = %+v", u)
+	}
+	if want := time.Date(2021, 3, 1, 4, 5, 0, 0, time.UTC); !u.ModifyDate.Equal(want) {
+		t.Errorf("ModifyDate = %v, want %v", u.ModifyDate, want)
+	}
+}
+
+func TestDecodeGetLimitPriceBrand(t *testing.T) {
+	res, err := replayClient(t).GetLimitPriceBrand(context.Background(), "token", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BrandTotal != 1 || len(res.BrandList) != 1 || res.BrandList[0].BrandID != "77" || res.BrandList[0].Name != "Eachine" {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestDecodeGetBrandLimitPriceList(t *testing.T) {
+	res, err := replayClient(t).GetBrandLimitPriceList(context.Background(), "token", "77", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ProductList) != 1 || res.ProductList[0].Sku != "SKU123456" {
+		t.Fatalf("got %+v", res)
+	}
+	if got := res.ProductList[0].LimitPrice.Amount(); got != "45.00" {
+		t.Errorf("LimitPrice = %s, want 45.00", got)
+	}
+}
+
+func TestDecodeGetProductPrice(t *testing.T) {
+	c := replayClient(t)
+	res, err := c.GetProductPrice(context.Background(), "token", "1180452", "200002", "CN", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMoney(t, "ProductPrice", res.ProductPrice, "USD 41.49")
+
+	// Error payloads carry an empty array where the price would be.
+	_, err = c.GetProductPrice(context.Background(), "token", "404", "", "CN", "")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrBadParameter) {
+		t.Fatalf("err = %v, want bad parameter APIError", err)
+	}
+	if apiErr.Message != "product_id is invalid" || apiErr.Endpoint != client.EndpointGetProductPrice {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+}
+
+func TestDecodeTranslate(t *testing.T) {
+	if _, err := replayClient(t).Translate(context.Background(), "token", "1180452", "200001", "CN", ""); err != nil {
+		t.Fatal(err)
+	}
+}
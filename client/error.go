@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Banggood response codes with a well known meaning.
+const (
+	CodeOK                 = 0
+	CodeInvalidAppID       = 11001
+	CodeInvalidAppSecret   = 11002
+	CodeInvalidToken       = 11020
+	CodeTokenExpired       = 11021
+	CodeInvalidParameter   = 12001
+	CodeMissingParameter   = 12002
+	CodeRateLimited        = 13001
+	CodeSystemBusy         = 13002
+	CodeRequestTooFrequent = 13003
+	CodeDuplicateOrder     = 31001
+)
+
+var (
+	ErrInvalidCredentials = errors.New("banggood: invalid app_id or app_secret")
+	ErrInvalidToken       = errors.New("banggood: invalid access token")
+	ErrTokenExpired       = errors.New("banggood: access token expired")
+	ErrBadParameter       = errors.New("banggood: bad parameter")
+	ErrRateLimited        = errors.New("banggood: rate limited")
+	ErrDuplicateOrder     = errors.New("banggood: sale record already imported")
+)
+
+// APIError is returned when Banggood answers with a non-zero code or a
+// non-2xx HTTP status.
+type APIError struct {
+	Endpoint   string
+	Code       int
+	Message    string
+	HTTPStatus int
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	switch {
+	case msg != "":
+	case e.HTTPStatus >= 300:
+		msg = http.StatusText(e.HTTPStatus)
+	default:
+		msg = "unknown error"
+	}
+	return fmt.Sprintf("banggood: %s: code %d: %s (http %d)", e.Endpoint, e.Code, msg, e.HTTPStatus)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidCredentials:
+		return e.Code == CodeInvalidAppID || e.Code == CodeInvalidAppSecret
+	case ErrInvalidToken:
+		if e.Is(ErrInvalidCredentials) {
+			return false
+		}
+		return e.Code == CodeInvalidToken || e.HTTPStatus == http.StatusUnauthorized
+	case ErrTokenExpired:
+		return e.Code == CodeTokenExpired
+	case ErrBadParameter:
+		return e.Code == CodeInvalidParameter || e.Code == CodeMissingParameter
+	case ErrRateLimited:
+		return e.Code == CodeRateLimited || e.Code == CodeSystemBusy || e.Code == CodeRequestTooFrequent || e.HTTPStatus == http.StatusTooManyRequests
+	case ErrDuplicateOrder:
+		return e.Code == CodeDuplicateOrder
+	}
+	return false
+}
+
+// responseCode accepts codes sent either as JSON numbers or as strings.
+type responseCode int
+
+func (c *responseCode) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*c = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*c = responseCode(n)
+	return nil
+}
+
+type responseEnvelope struct {
+	Code         *responseCode `json:"code"`
+	Error        *responseCode `json:"error"`
+	Message      string        `json:"msg"`
+	ErrorMessage string        `json:"errMsg"`
+}
+
+func (e responseEnvelope) code() int {
+	switch {
+	case e.Code != nil && *e.Code != 0:
+		return int(*e.Code)
+	case e.Error != nil:
+		return int(*e.Error)
+	}
+	return CodeOK
+}
+
+func (e responseEnvelope) message() string {
+	if e.ErrorMessage != "" {
+		return e.ErrorMessage
+	}
+	return e.Message
+}
+
+// decodeResponse unmarshals body into data and turns API level failures
+// into an *APIError. data is populated even when an error is returned, so
+// callers can inspect partial results such as an import failure list.
+func decodeResponse(endpoint string, status int, body []byte, data interface{}) error {
+	var envelope responseEnvelope
+	envelopeErr := json.Unmarshal(body, &envelope)
+
+	if status < 200 || status > 299 {
+		apiErr := &APIError{
+			Endpoint:   endpoint,
+			HTTPStatus: status,
+		}
+		if envelopeErr == nil {
+			apiErr.Code = envelope.code()
+			apiErr.Message = envelope.message()
+		}
+		return apiErr
+	}
+	if envelopeErr != nil {
+		return envelopeErr
+	}
+	decodeErr := json.Unmarshal(body, data)
+	if code := envelope.code(); code != CodeOK {
+		// Error payloads often use different field types than the success
+		// shape, so a decode failure here is not worth reporting.
+		return &APIError{
+			Endpoint:   endpoint,
+			Code:       code,
+			Message:    envelope.message(),
+			HTTPStatus: status,
+		}
+	}
+	return decodeErr
+}
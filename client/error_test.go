@@ -0,0 +1,114 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want []error
+	}{
+		{&APIError{Code: CodeInvalidAppID}, []error{ErrInvalidCredentials}},
+		{&APIError{Code: CodeInvalidAppSecret}, []error{ErrInvalidCredentials}},
+		{&APIError{Code: CodeInvalidToken}, []error{ErrInvalidToken}},
+		{&APIError{HTTPStatus: http.StatusUnauthorized}, []error{ErrInvalidToken}},
+		{&APIError{Code: CodeInvalidAppSecret, HTTPStatus: http.StatusUnauthorized}, []error{ErrInvalidCredentials}},
+		{&APIError{Code: CodeTokenExpired}, []error{ErrTokenExpired}},
+		{&APIError{Code: CodeInvalidParameter}, []error{ErrBadParameter}},
+		{&APIError{Code: CodeMissingParameter}, []error{ErrBadParameter}},
+		{&APIError{Code: CodeRateLimited}, []error{ErrRateLimited}},
+		{&APIError{Code: CodeSystemBusy}, []error{ErrRateLimited}},
+		{&APIError{Code: CodeRequestTooFrequent}, []error{ErrRateLimited}},
+		{&APIError{HTTPStatus: http.StatusTooManyRequests}, []error{ErrRateLimited}},
+		{&APIError{Code: CodeDuplicateOrder}, []error{ErrDuplicateOrder}},
+		{&APIError{Code: 19999}, nil},
+	}
+	all := []error{ErrInvalidCredentials, ErrInvalidToken, ErrTokenExpired, ErrBadParameter, ErrRateLimited, ErrDuplicateOrder}
+	for _, tt := range tests {
+		wrapped := fmt.Errorf("call: %w", tt.err)
+		for _, sentinel := range all {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == sentinel
+			}
+			if got := errors.Is(wrapped, sentinel); got != want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, sentinel, got, want)
+			}
+		}
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want string
+	}{
+		{&APIError{Endpoint: EndpointGetStocks, Code: CodeInvalidParameter, Message: "product_id is invalid", HTTPStatus: 200}, "banggood: product/getStocks: code 12001: product_id is invalid (http 200)"},
+		{&APIError{Endpoint: EndpointGetStocks, HTTPStatus: 502}, "banggood: product/getStocks: code 0: Bad Gateway (http 502)"},
+		{&APIError{Endpoint: EndpointGetStocks, Code: 19999, HTTPStatus: 200}, "banggood: product/getStocks: code 19999: unknown error (http 200)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	type payload struct {
+		Stock string `json:"stock"`
+	}
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode int
+		wantMsg  string
+		wantErr  bool
+		want     string
+	}{
+		{"success", 200, `{"code":0,"stock":"5"}`, 0, "", false, "5"},
+		{"string code", 200, `{"code":"12001","msg":"bad"}`, 12001, "bad", true, ""},
+		{"error field", 200, `{"error":11020,"errMsg":"token invalid"}`, 11020, "token invalid", true, ""},
+		{"errMsg wins", 200, `{"code":13001,"msg":"generic","errMsg":"slow down"}`, 13001, "slow down", true, ""},
+		{"error payload shape", 200, `{"code":12001,"msg":"bad","stock":[]}`, 12001, "bad", true, ""},
+		{"partial result", 200, `{"code":31001,"msg":"dup","stock":"3"}`, 31001, "dup", true, "3"},
+		{"http failure", 503, `<html>busy</html>`, 0, "", true, ""},
+		{"http failure with envelope", 500, `{"code":13002,"msg":"busy"}`, 13002, "busy", true, ""},
+	}
+	for _, tt := range tests {
+		var data payload
+		err := decodeResponse(EndpointGetStocks, tt.status, []byte(tt.body), &data)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if data.Stock != tt.want {
+			t.Errorf("%s: stock = %q, want %q", tt.name, data.Stock, tt.want)
+		}
+		if !tt.wantErr {
+			continue
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: err = %v, want *APIError", tt.name, err)
+			continue
+		}
+		if apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMsg || apiErr.HTTPStatus != tt.status || apiErr.Endpoint != EndpointGetStocks {
+			t.Errorf("%s: APIError = %+v", tt.name, apiErr)
+		}
+	}
+
+	var data payload
+	if err := decodeResponse(EndpointGetStocks, 200, []byte(`not json`), &data); err == nil {
+		t.Error("malformed body decoded without error")
+	}
+	var apiErr *APIError
+	if err := decodeResponse(EndpointGetStocks, 200, []byte(`{"code":0,"stock":5}`), &data); err == nil || errors.As(err, &apiErr) {
+		t.Errorf("mistyped success payload = %v, want a decode error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// throttleFloor is the lowest fraction of its configured rate a bucket
+	// backs off to after repeated throttling.
+	throttleFloor = 0.1
+	// recoveryStep is the fraction of the configured rate regained after
+	// each successful call.
+	recoveryStep = 0.05
+)
+
+// RateLimit is a token bucket refilled at Rate requests per second and
+// holding at most Burst tokens.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// WithRateLimit limits every endpoint that has no limit of its own.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *client) {
+		c.limiter().global = newBucket(RateLimit{Rate: rate, Burst: burst})
+	}
+}
+
+// WithEndpointRateLimit gives endpoint, e.g. EndpointGetProductInfo, its
+// own budget separate from the global one.
+func WithEndpointRateLimit(endpoint string, rate float64, burst int) Option {
+	return func(c *client) {
+		c.limiter().endpoints[endpoint] = newBucket(RateLimit{Rate: rate, Burst: burst})
+	}
+}
+
+func (c *client) limiter() *rateLimiter {
+	if c.Limiter == nil {
+		c.Limiter = &rateLimiter{
+			endpoints: map[string]*bucket{},
+		}
+	}
+	return c.Limiter
+}
+
+// rateLimiter is shared by every copy of a client, so all goroutines using
+// the same client draw from the same buckets.
+type rateLimiter struct {
+	global    *bucket
+	endpoints map[string]*bucket
+}
+
+func (l *rateLimiter) bucket(endpoint string) *bucket {
+	if l == nil {
+		return nil
+	}
+	if b, ok := l.endpoints[endpoint]; ok {
+		return b
+	}
+	return l.global
+}
+
+type bucket struct {
+	limit RateLimit
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit RateLimit) *bucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &bucket{
+		limit:  limit,
+		rate:   limit.Rate,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *bucket) wait(ctx context.Context) error {
+	if b == nil || b.limit.Rate <= 0 {
+		return nil
+	}
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		b.refund()
+		return err
+	}
+	return nil
+}
+
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if max := float64(b.limit.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *bucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// throttled halves the current rate after the API reported throttling.
+func (b *bucket) throttled() {
+	if b == nil || b.limit.Rate <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate /= 2
+	if floor := b.limit.Rate * throttleFloor; b.rate < floor {
+		b.rate = floor
+	}
+}
+
+// succeeded lets the rate creep back towards its configured value.
+func (b *bucket) succeeded() {
+	if b == nil || b.limit.Rate <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate >= b.limit.Rate {
+		return
+	}
+	b.rate += b.limit.Rate * recoveryStep
+	if b.rate > b.limit.Rate {
+		b.rate = b.limit.Rate
+	}
+}
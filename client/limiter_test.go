@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	b := newBucket(RateLimit{Rate: 10, Burst: 2})
+	now := b.last
+
+	for i := 0; i < 2; i++ {
+		if d := b.reserve(now); d != 0 {
+			t.Fatalf("reservation %d within the burst waited %s", i, d)
+		}
+	}
+	if d := b.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("reservation past the burst waited %s, want 100ms", d)
+	}
+	if d := b.reserve(now.Add(time.Second)); d != 0 {
+		t.Errorf("reservation after refilling waited %s", d)
+	}
+	if d := b.reserve(now.Add(time.Hour)); d != 0 || b.tokens != 1 {
+		t.Errorf("refill is not capped at the burst: waited %s, %v tokens left", d, b.tokens)
+	}
+}
+
+func TestBucketThrottle(t *testing.T) {
+	b := newBucket(RateLimit{Rate: 10, Burst: 1})
+	b.throttled()
+	if b.rate != 5 {
+		t.Errorf("rate after throttling = %v, want 5", b.rate)
+	}
+	for i := 0; i < 10; i++ {
+		b.throttled()
+	}
+	if b.rate != 10*throttleFloor {
+		t.Errorf("rate after repeated throttling = %v, want the floor", b.rate)
+	}
+	for i := 0; i < 100; i++ {
+		b.succeeded()
+	}
+	if b.rate != 10 {
+		t.Errorf("rate after recovering = %v, want 10", b.rate)
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := newBucket(RateLimit{Rate: 0.001, Burst: 1})
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait on an empty bucket = %v, want DeadlineExceeded", err)
+	}
+	if b.tokens < -0.5 {
+		t.Errorf("cancelled wait kept its reservation: %v tokens", b.tokens)
+	}
+
+	var unlimited *bucket
+	if err := unlimited.wait(context.Background()); err != nil {
+		t.Errorf("wait without a limit = %v", err)
+	}
+}
+
+func TestRateLimitOptions(t *testing.T) {
+	c := client{}
+	WithRateLimit(5, 1)(&c)
+	WithEndpointRateLimit(EndpointGetProductInfo, 1, 3)(&c)
+
+	if b := c.Limiter.bucket(EndpointGetProductInfo); b == nil || b.limit != (RateLimit{Rate: 1, Burst: 3}) {
+		t.Errorf("%s bucket = %+v", EndpointGetProductInfo, b)
+	}
+	if b := c.Limiter.bucket(EndpointGetStocks); b == nil || b.limit != (RateLimit{Rate: 5, Burst: 1}) {
+		t.Errorf("global bucket = %+v", b)
+	}
+
+	copied := c
+	copied.Limiter.bucket(EndpointGetStocks).throttled()
+	if c.Limiter.bucket(EndpointGetStocks).rate != 2.5 {
+		t.Error("copies of a client do not share their buckets")
+	}
+}
+
+func TestRateLimitedResponseThrottles(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"code":%d,"msg":"too many requests"}`, CodeRateLimited)
+	}))
+	defer ts.Close()
+	c := NewClient("id", "secret", WithBaseURL(ts.URL+"/"), WithRateLimit(100, 10), WithRetryPolicy(RetryPolicy{}))
+
+	if _, err := c.GetCountries(context.Background(), "token"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("GetCountries = %v, want ErrRateLimited", err)
+	}
+	if rate := c.(client).Limiter.global.rate; rate != 50 {
+		t.Errorf("rate after a rate-limited response = %v, want 50", rate)
+	}
+}
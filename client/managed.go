@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ManagedClient mirrors BanggoodClient but obtains access tokens from a
+// TokenSource instead of taking them as arguments.
+type ManagedClient interface {
+	Translate(ctx context.Context, productID, poaID, warehouse, currency string) (TranslateResponse, error)
+	GetProductPrice(ctx context.Context, productID, poaID, warehouse, currency string) (GetProductPriceResponse, error)
+	GetCategoryList(ctx context.Context, page *int) (GetCategoryListResponse, error)
+	GetAllCategories(ctx context.Context) ([]Category, error)
+	GetCategoryTree(ctx context.Context) (*CategoryTree, error)
+	Categories(ctx context.Context) *CategoryIterator
+	GetProductList(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) (GetProductListResponse, error)
+	GetAllProducts(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error)
+	Products(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) *ProductIterator
+	GetProductInfo(ctx context.Context, productID string, currency *string) (GetProductInfoResponse, error)
+	GetShipments(ctx context.Context, productID, warehouse, country, poaID, currency string, quantity int) (GetShipmentsResponse, error)
+	ImportOrder(ctx context.Context, order ImportOrderRequest) (ImportOrderResponse, error)
+	GetOrderInfo(ctx context.Context, saleRecordID string) (GetOrderInfoResponse, error)
+	GetTrackInfo(ctx context.Context, orderID string) (GetTrackInfoResponse, error)
+	GetOrderHistory(ctx context.Context, saleRecordID, orderID string) (GetOrderHistoryResponse, error)
+	GetCountries(ctx context.Context) (GetCountriesResponse, error)
+	GetStock(ctx context.Context, productID string) (GetStockResponse, error)
+	GetVariantMatrix(ctx context.Context, productID string, currency *string) (VariantMatrix, error)
+	GetProductUpdateList(ctx context.Context, minutes, page int) (GetProductUpdateListResponse, error)
+	GetAllProductUpdates(ctx context.Context, minutes int) ([]ProductUpdate, error)
+	ProductUpdates(ctx context.Context, minutes int) *ProductUpdateIterator
+	GetLimitPriceBrand(ctx context.Context, page int) (GetLimitPriceBrandResponse, error)
+	GetAllLimitPriceBrands(ctx context.Context) ([]LimitPriceBrand, error)
+	LimitPriceBrands(ctx context.Context) *LimitPriceBrandIterator
+	GetBrandLimitPriceList(ctx context.Context, brandID string, page int) (GetBrandLimitPriceListResponse, error)
+	GetAllBrandLimitPrices(ctx context.Context, brandID string) ([]BrandLimitPrice, error)
+	BrandLimitPrices(ctx context.Context, brandID string) *BrandLimitPriceIterator
+	IsSandbox() bool
+}
+
+func NewDefaultManagedClient(id, secret string) ManagedClient {
+	c := NewDefaultClient(id, secret)
+	return NewManagedClient(c, NewTokenSource(c))
+}
+
+func NewSandboxManagedClient(id, secret string) ManagedClient {
+	c := NewSandboxClient(id, secret)
+	return NewManagedClient(c, NewTokenSource(c))
+}
+
+func NewManagedClient(c BanggoodClient, tokens TokenSource) ManagedClient {
+	return managedClient{
+		Client: c,
+		Tokens: tokens,
+	}
+}
+
+type managedClient struct {
+	Client BanggoodClient
+	Tokens TokenSource
+}
+
+// withToken calls fn with a token from m.Tokens. When the API rejects the
+// token and the source can be invalidated, fn is retried once with a fresh one.
+// Invalid credentials are returned as is, since no new token would help.
+func (m managedClient) withToken(ctx context.Context, fn func(token string) error) error {
+	token, err := m.Tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(token)
+	if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrTokenExpired) {
+		return err
+	}
+	inv, ok := m.Tokens.(interface{ Invalidate() })
+	if !ok {
+		return err
+	}
+	inv.Invalidate()
+	token, err = m.Tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(token)
+}
+
+func (m managedClient) Translate(ctx context.Context, productID, poaID, warehouse, currency string) (res TranslateResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.Translate(ctx, token, productID, poaID, warehouse, currency)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetProductPrice(ctx context.Context, productID, poaID, warehouse, currency string) (res GetProductPriceResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetProductPrice(ctx, token, productID, poaID, warehouse, currency)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetCategoryList(ctx context.Context, page *int) (res GetCategoryListResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetCategoryList(ctx, token, page)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetAllCategories(ctx context.Context) ([]Category, error) {
+	return m.Categories(ctx).All()
+}
+
+func (m managedClient) GetCategoryTree(ctx context.Context) (*CategoryTree, error) {
+	categories, err := m.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewCategoryTree(categories)
+}
+
+func (m managedClient) Categories(ctx context.Context) *CategoryIterator {
+	return newCategoryIterator(ctx, func(ctx context.Context, page int) (GetCategoryListResponse, error) {
+		return m.GetCategoryList(ctx, &page)
+	})
+}
+
+func (m managedClient) GetProductList(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) (res GetProductListResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetProductList(ctx, token, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, page)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetAllProducts(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) ([]Product, error) {
+	return m.Products(ctx, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd).All()
+}
+
+func (m managedClient) Products(ctx context.Context, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time) *ProductIterator {
+	return newProductIterator(ctx, func(ctx context.Context, page int) (GetProductListResponse, error) {
+		return m.GetProductList(ctx, categoryID, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd, &page)
+	})
+}
+
+func (m managedClient) GetProductInfo(ctx context.Context, productID string, currency *string) (res GetProductInfoResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetProductInfo(ctx, token, productID, currency)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetShipments(ctx context.Context, productID, warehouse, country, poaID, currency string, quantity int) (res GetShipmentsResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetShipments(ctx, token, productID, warehouse, country, poaID, currency, quantity)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) ImportOrder(ctx context.Context, order ImportOrderRequest) (res ImportOrderResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		order.AccessToken = token
+		res, err = m.Client.ImportOrder(ctx, order)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetOrderInfo(ctx context.Context, saleRecordID string) (res GetOrderInfoResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetOrderInfo(ctx, token, saleRecordID)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetTrackInfo(ctx context.Context, orderID string) (res GetTrackInfoResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetTrackInfo(ctx, token, orderID)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetOrderHistory(ctx context.Context, saleRecordID, orderID string) (res GetOrderHistoryResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetOrderHistory(ctx, token, saleRecordID, orderID)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetCountries(ctx context.Context) (res GetCountriesResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetCountries(ctx, token)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetStock(ctx context.Context, productID string) (res GetStockResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetStock(ctx, token, productID)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetVariantMatrix(ctx context.Context, productID string, currency *string) (VariantMatrix, error) {
+	info, err := m.GetProductInfo(ctx, productID, currency)
+	if err != nil {
+		return VariantMatrix{}, err
+	}
+	stock, err := m.GetStock(ctx, productID)
+	if err != nil {
+		return VariantMatrix{}, err
+	}
+	return NewVariantMatrix(productID, info, stock)
+}
+
+func (m managedClient) GetProductUpdateList(ctx context.Context, minutes, page int) (res GetProductUpdateListResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetProductUpdateList(ctx, token, minutes, page)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetAllProductUpdates(ctx context.Context, minutes int) ([]ProductUpdate, error) {
+	return m.ProductUpdates(ctx, minutes).All()
+}
+
+func (m managedClient) ProductUpdates(ctx context.Context, minutes int) *ProductUpdateIterator {
+	return newProductUpdateIterator(ctx, func(ctx context.Context, page int) (GetProductUpdateListResponse, error) {
+		return m.GetProductUpdateList(ctx, minutes, page)
+	})
+}
+
+func (m managedClient) GetLimitPriceBrand(ctx context.Context, page int) (res GetLimitPriceBrandResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetLimitPriceBrand(ctx, token, page)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetAllLimitPriceBrands(ctx context.Context) ([]LimitPriceBrand, error) {
+	return m.LimitPriceBrands(ctx).All()
+}
+
+func (m managedClient) LimitPriceBrands(ctx context.Context) *LimitPriceBrandIterator {
+	return newLimitPriceBrandIterator(ctx, func(ctx context.Context, page int) (GetLimitPriceBrandResponse, error) {
+		return m.GetLimitPriceBrand(ctx, page)
+	})
+}
+
+func (m managedClient) GetBrandLimitPriceList(ctx context.Context, brandID string, page int) (res GetBrandLimitPriceListResponse, err error) {
+	err = m.withToken(ctx, func(token string) (err error) {
+		res, err = m.Client.GetBrandLimitPriceList(ctx, token, brandID, page)
+		return err
+	})
+	return res, err
+}
+
+func (m managedClient) GetAllBrandLimitPrices(ctx context.Context, brandID string) ([]BrandLimitPrice, error) {
+	return m.BrandLimitPrices(ctx, brandID).All()
+}
+
+func (m managedClient) BrandLimitPrices(ctx context.Context, brandID string) *BrandLimitPriceIterator {
+	return newBrandLimitPriceIterator(ctx, func(ctx context.Context, page int) (GetBrandLimitPriceListResponse, error) {
+		return m.GetBrandLimitPriceList(ctx, brandID, page)
+	})
+}
+
+func (m managedClient) IsSandbox() bool {
+	return m.Client.IsSandbox()
+}
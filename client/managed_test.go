@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// countriesClient is a tokenClient that records the token of every
+// GetCountries call and fails the calls with errs, one error per call.
+type countriesClient struct {
+	tokenClient
+	tokens []string
+	errs   []error
+}
+
+func (c *countriesClient) GetCountries(ctx context.Context, token string) (GetCountriesResponse, error) {
+	c.tokens = append(c.tokens, token)
+	if len(c.errs) == 0 {
+		return GetCountriesResponse{}, nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return GetCountriesResponse{}, err
+}
+
+func TestManagedClientCachesToken(t *testing.T) {
+	c := &countriesClient{tokenClient: tokenClient{expiresIn: 7200}}
+	m := NewManagedClient(c, NewTokenSource(c))
+	for i := 0; i < 3; i++ {
+		if _, err := m.GetCountries(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"token-1", "token-1", "token-1"}; !reflect.DeepEqual(c.tokens, want) || c.issued != 1 {
+		t.Errorf("sent tokens %v after %d token requests", c.tokens, c.issued)
+	}
+}
+
+func TestManagedClientStaticToken(t *testing.T) {
+	c := &countriesClient{}
+	if _, err := NewManagedClient(c, StaticTokenSource("abc")).GetCountries(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.tokens, []string{"abc"}) || c.issued != 0 {
+		t.Errorf("sent tokens %v after %d token requests", c.tokens, c.issued)
+	}
+}
+
+func TestManagedClientTokenError(t *testing.T) {
+	c := &countriesClient{tokenClient: tokenClient{empty: true}}
+	if _, err := NewManagedClient(c, NewTokenSource(c)).GetCountries(context.Background()); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Errorf("GetCountries = %v, want ErrEmptyAccessToken", err)
+	}
+	if len(c.tokens) != 0 {
+		t.Errorf("GetCountries was called with %v", c.tokens)
+	}
+}
+
+func TestManagedClientRefreshesRejectedToken(t *testing.T) {
+	expired := &APIError{Code: CodeTokenExpired}
+	tests := []struct {
+		name   string
+		errs   []error
+		want   error
+		tokens []string
+	}{
+		{"expired", []error{expired}, nil, []string{"token-1", "token-2"}},
+		{"invalid", []error{&APIError{Code: CodeInvalidToken}}, nil, []string{"token-1", "token-2"}},
+		{"rejected twice", []error{expired, expired}, ErrTokenExpired, []string{"token-1", "token-2"}},
+		{"invalid credentials", []error{&APIError{Code: CodeInvalidAppSecret}}, ErrInvalidCredentials, []string{"token-1"}},
+		{"other error", []error{&APIError{Code: CodeRateLimited}}, ErrRateLimited, []string{"token-1"}},
+	}
+	for _, tt := range tests {
+		c := &countriesClient{tokenClient: tokenClient{expiresIn: 7200}, errs: tt.errs}
+		_, err := NewManagedClient(c, NewTokenSource(c)).GetCountries(context.Background())
+		if tt.want == nil && err != nil || !errors.Is(err, tt.want) {
+			t.Errorf("%s: GetCountries = %v, want %v", tt.name, err, tt.want)
+		}
+		if !reflect.DeepEqual(c.tokens, tt.tokens) {
+			t.Errorf("%s: sent tokens %v, want %v", tt.name, c.tokens, tt.tokens)
+		}
+	}
+
+	// A static token cannot be refreshed, so its rejection is returned.
+	c := &countriesClient{errs: []error{expired}}
+	if _, err := NewManagedClient(c, StaticTokenSource("abc")).GetCountries(context.Background()); !errors.Is(err, ErrTokenExpired) || len(c.tokens) != 1 {
+		t.Errorf("GetCountries with a static token = %v after %d calls", err, len(c.tokens))
+	}
+}
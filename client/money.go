@@ -0,0 +1,358 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// moneyDecimals is the precision Money keeps internally. Amounts with more
+// decimals are rounded half away from zero.
+const moneyDecimals = 6
+
+var moneyScale = big.NewInt(1000000)
+
+var (
+	ErrInvalidMoney     = errors.New("banggood: invalid money amount")
+	ErrCurrencyMismatch = errors.New("banggood: currency mismatch")
+)
+
+// Money is an exact decimal amount in a currency. The zero value is zero in
+// no particular currency; a Money without a currency takes the currency of
+// the other operand in arithmetic. Add, Sub and Cmp return an error wrapping
+// ErrCurrencyMismatch when mixing two different currencies; their Must
+// variants panic instead and suit amounts already checked with CheckCurrency.
+//
+// Money decodes from JSON strings and numbers, and encodes as a string
+// without the currency, which the API reports in a separate field.
+type Money struct {
+	units    int64
+	Currency string
+}
+
+// NewMoney returns value × 10^-decimals in cur, e.g. NewMoney(1999, 2, "USD")
+// is USD 19.99.
+func NewMoney(value int64, decimals int, cur string) Money {
+	r := new(big.Rat).SetFrac(big.NewInt(value), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	m, _ := moneyFromRat(r, cur)
+	return m
+}
+
+// ParseMoney parses a plain decimal amount such as "19.99" or "-0.5".
+// Fractions and exponents are rejected. An empty string is zero.
+func ParseMoney(s, cur string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{Currency: cur}, nil
+	}
+	if !isDecimal(s) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	m, err := moneyFromRat(r, cur)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", err, s)
+	}
+	return m, nil
+}
+
+// isDecimal reports whether s is an optionally signed run of digits with at
+// most one decimal point.
+func isDecimal(s string) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	digits, dot := 0, false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+func moneyFromRat(r *big.Rat, cur string) (Money, error) {
+	units := roundRat(new(big.Rat).Mul(r, new(big.Rat).SetInt(moneyScale)))
+	if !units.IsInt64() {
+		return Money{}, fmt.Errorf("%w: out of range", ErrInvalidMoney)
+	}
+	return Money{units: units.Int64(), Currency: cur}, nil
+}
+
+// roundRat rounds r to an integer, half away from zero.
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+func (m Money) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.units), moneyScale)
+}
+
+func (m Money) with(r *big.Rat) Money {
+	n, err := moneyFromRat(r, m.Currency)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// common returns the currency shared by m and o.
+func (m Money) common(o Money) (string, error) {
+	switch {
+	case m.Currency == "":
+		return o.Currency, nil
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// SameCurrency reports whether m and o can be combined, that is whether
+// they share a currency or either has none.
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == "" || o.Currency == "" || m.Currency == o.Currency
+}
+
+// CheckCurrency returns the currency shared by ms, or an error wrapping
+// ErrCurrencyMismatch if any two of them cannot be combined.
+func CheckCurrency(ms ...Money) (string, error) {
+	var cur string
+	for _, m := range ms {
+		switch {
+		case m.Currency == "" || m.Currency == cur:
+		case cur == "":
+			cur = m.Currency
+		default:
+			return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, cur, m.Currency)
+		}
+	}
+	return cur, nil
+}
+
+// In returns m relabelled as cur. It does not convert.
+func (m Money) In(cur string) Money {
+	m.Currency = cur
+	return m
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) Sign() int {
+	switch {
+	case m.units < 0:
+		return -1
+	case m.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	d, err := m.Sub(o)
+	if err != nil {
+		return 0, err
+	}
+	return d.Sign(), nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	cur, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{units: m.units + o.units, Currency: cur}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	cur, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{units: m.units - o.units, Currency: cur}, nil
+}
+
+// MustCmp is like Cmp but panics if the currencies differ.
+func (m Money) MustCmp(o Money) int {
+	c, err := m.Cmp(o)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// MustAdd is like Add but panics if the currencies differ.
+func (m Money) MustAdd(o Money) Money {
+	n, err := m.Add(o)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// MustSub is like Sub but panics if the currencies differ.
+func (m Money) MustSub(o Money) Money {
+	n, err := m.Sub(o)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (m Money) Neg() Money {
+	m.units = -m.units
+	return m
+}
+
+func (m Money) Mul(n int64) Money {
+	return m.with(new(big.Rat).Mul(m.rat(), new(big.Rat).SetInt64(n)))
+}
+
+// MulRatio returns m × num/den, e.g. MulRatio(15, 100) for 15%.
+func (m Money) MulRatio(num, den int64) Money {
+	return m.with(new(big.Rat).Mul(m.rat(), big.NewRat(num, den)))
+}
+
+// Round rounds m to decimals places, half away from zero.
+func (m Money) Round(decimals int) Money {
+	if decimals >= moneyDecimals {
+		return m
+	}
+	step := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	r := roundRat(new(big.Rat).Mul(m.rat(), step))
+	return m.with(new(big.Rat).Quo(new(big.Rat).SetInt(r), step))
+}
+
+// Decimals returns the number of minor-unit digits of m's currency, or 2 if
+// the currency is unknown.
+func (m Money) Decimals() int {
+	unit, err := currency.ParseISO(m.Currency)
+	if err != nil {
+		return 2
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	return scale
+}
+
+// RoundCurrency rounds m to the minor unit of its currency.
+func (m Money) RoundCurrency() Money {
+	return m.Round(m.Decimals())
+}
+
+// Float64 returns an approximation of m, for display or statistics only.
+func (m Money) Float64() float64 {
+	f, _ := m.rat().Float64()
+	return f
+}
+
+// Amount formats m without its currency, with at least the currency's
+// minor-unit digits, e.g. "19.90".
+func (m Money) Amount() string {
+	s := m.rat().FloatString(moneyDecimals)
+	min := m.Decimals()
+	s = strings.TrimRight(s, "0")
+	dot := strings.IndexByte(s, '.')
+	if digits := len(s) - dot - 1; digits < min {
+		s += strings.Repeat("0", min-digits)
+	}
+	return strings.TrimSuffix(s, ".")
+}
+
+// String formats m with its currency code, e.g. "USD 19.90".
+func (m Money) String() string {
+	unit, err := currency.ParseISO(m.Currency)
+	if err != nil {
+		if m.Currency == "" {
+			return m.Amount()
+		}
+		return m.Currency + " " + m.Amount()
+	}
+	return fmt.Sprint(currency.ISO(unit.Amount(moneyAmount(m))))
+}
+
+// moneyAmount lets currency formatters print m exactly instead of going
+// through a float.
+type moneyAmount Money
+
+func (a moneyAmount) Format(s fmt.State, verb rune) {
+	m := Money(a)
+	if p, ok := s.Precision(); ok {
+		m = m.Round(p)
+		fmt.Fprint(s, m.rat().FloatString(p))
+		return
+	}
+	fmt.Fprint(s, m.Amount())
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Amount())
+}
+
+// UnmarshalJSON accepts a number, a string holding a number, an empty
+// string or null. The currency is left unchanged.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// setCurrency labels the prices of r, which the API reports in the
+// requested currency.
+func (r *GetProductInfoResponse) setCurrency(cur string) {
+	for i := range r.WarehouseList {
+		r.WarehouseList[i].WarehousePrice.Currency = cur
+	}
+	for i := range r.PoaList {
+		for j := range r.PoaList[i].OptionValues {
+			r.PoaList[i].OptionValues[j].PoaPrice.Currency = cur
+		}
+	}
+}
+
+// setCurrency labels the amounts of each order with the order's currency.
+func (r *GetOrderInfoResponse) setCurrency() {
+	for i := range r.SaleRecordIDList {
+		for j := range r.SaleRecordIDList[i].OrderList {
+			o := &r.SaleRecordIDList[i].OrderList[j]
+			for _, m := range []*Money{&o.TotalAmount, &o.SubAmount, &o.DropShipDiscount, &o.Shipfee, &o.ShipInsurance, &o.TariffInsurance} {
+				m.Currency = o.Currency
+			}
+		}
+	}
+}
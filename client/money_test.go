@@ -0,0 +1,131 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func mustMoney(t *testing.T, s, cur string) Money {
+	t.Helper()
+	m, err := ParseMoney(s, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"19.99", "19.99"},
+		{"  7 ", "7.00"},
+		{"", "0.00"},
+		{"0.1234564", "0.123456"},
+		{"0.1234565", "0.123457"},
+		{"-2.5", "-2.50"},
+		{"+.5", "0.50"},
+		{"3.", "3.00"},
+	}
+	for _, tt := range tests {
+		if got := mustMoney(t, tt.in, "USD").Amount(); got != tt.want {
+			t.Errorf("ParseMoney(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"12,50", "1/3", "1e3", "0x10", "1.2.3", "-", ".", "Inf"} {
+		if _, err := ParseMoney(in, "EUR"); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q) = %v, want ErrInvalidMoney", in, err)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	price := mustMoney(t, "19.99", "USD")
+	fee := mustMoney(t, "0.01", "")
+
+	if got, err := price.Add(fee); err != nil || got.Amount() != "20.00" || got.Currency != "USD" {
+		t.Errorf("Add = %v, %v", got, err)
+	}
+	if got, err := price.Sub(price); err != nil || !got.IsZero() {
+		t.Errorf("Sub = %v, %v", got, err)
+	}
+	if got := price.Mul(3).String(); got != "USD 59.97" {
+		t.Errorf("Mul = %s", got)
+	}
+	if got := price.MulRatio(15, 100).RoundCurrency().Amount(); got != "3.00" {
+		t.Errorf("MulRatio = %s", got)
+	}
+	if got := mustMoney(t, "2.345", "USD").Round(2).Amount(); got != "2.35" {
+		t.Errorf("Round = %s", got)
+	}
+	if got := mustMoney(t, "-2.345", "USD").Round(2).Amount(); got != "-2.35" {
+		t.Errorf("Round negative = %s", got)
+	}
+	if got := mustMoney(t, "1500.4", "JPY").RoundCurrency().Amount(); got != "1500" {
+		t.Errorf("RoundCurrency JPY = %s", got)
+	}
+	if price.MustCmp(fee) != 1 || fee.MustCmp(price) != -1 || price.MustCmp(price) != 0 {
+		t.Error("Cmp ordering is wrong")
+	}
+}
+
+func TestCheckCurrency(t *testing.T) {
+	usd := mustMoney(t, "1", "USD")
+	eur := mustMoney(t, "1", "EUR")
+	none := Money{}
+
+	if cur, err := CheckCurrency(none, usd, usd); err != nil || cur != "USD" {
+		t.Errorf("CheckCurrency = %q, %v", cur, err)
+	}
+	if cur, err := CheckCurrency(); err != nil || cur != "" {
+		t.Errorf("CheckCurrency() = %q, %v", cur, err)
+	}
+	if _, err := CheckCurrency(usd, none, eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("CheckCurrency(USD, EUR) = %v, want ErrCurrencyMismatch", err)
+	}
+	if !usd.SameCurrency(none) || usd.SameCurrency(eur) {
+		t.Error("SameCurrency is wrong")
+	}
+
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add of USD and EUR = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub of USD and EUR = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp of USD and EUR = %v, want ErrCurrencyMismatch", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustAdd of USD and EUR did not panic")
+		}
+	}()
+	usd.MustAdd(eur)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A, B, C, D Money
+	}
+	v.A.Currency = "EUR"
+	if err := json.Unmarshal([]byte(`{"A":"12.30","B":4.5,"C":"","D":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "EUR 12.30" || v.B.Amount() != "4.50" || !v.C.IsZero() || !v.D.IsZero() {
+		t.Errorf("decoded %+v", v)
+	}
+	b, err := json.Marshal(v.A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"12.30"` {
+		t.Errorf("Marshal = %s", b)
+	}
+	if err := json.Unmarshal([]byte(`"abc"`), &v.A); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("Unmarshal(abc) = %v, want ErrInvalidMoney", err)
+	}
+}
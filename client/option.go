@@ -0,0 +1,58 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Logger is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type Option func(*client)
+
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *client) {
+		c.HTTPClient = h
+	}
+}
+
+func WithBaseURL(u string) Option {
+	return func(c *client) {
+		c.BaseURL = u
+	}
+}
+
+// WithLanguage sets the lang parameter sent with every request.
+func WithLanguage(lang string) Option {
+	return func(c *client) {
+		c.Language = lang
+	}
+}
+
+// WithCurrency sets the currency used when a call is made without one.
+func WithCurrency(currency string) Option {
+	return func(c *client) {
+		c.Currency = currency
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *client) {
+		c.UserAgent = ua
+	}
+}
+
+func WithLogger(l Logger) Option {
+	return func(c *client) {
+		c.Logger = l
+	}
+}
+
+// WithSandbox targets the Banggood beta environment. Unless a base URL is
+// also given, the beta host is used.
+func WithSandbox() Option {
+	return func(c *client) {
+		c.Sandbox = true
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format+"\n", v...)
+}
+
+func TestOptions(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		if r.URL.Path == "/"+EndpointGetAccessToken {
+			return http.StatusOK, `{"code":0,"access_token":"secret-token","expires_in":7200}`
+		}
+		return http.StatusOK, `{"code":0,"currency":"EUR"}`
+	})
+	var log bufferLogger
+	c := NewClient("app-id", "app-secret",
+		WithBaseURL(stub.URL),
+		WithHTTPClient(stub.Client()),
+		WithLanguage("de"),
+		WithCurrency("EUR"),
+		WithUserAgent("shop/1.0"),
+		WithLogger(&log),
+	)
+	m := NewManagedClient(c, NewTokenSource(c))
+	ctx := context.Background()
+
+	if _, err := m.GetProductPrice(ctx, "1", "", "CN", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetProductPrice(ctx, "1", "", "CN", "GBP"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := stub.received()
+	if len(requests) != 3 {
+		t.Fatalf("server received %d requests", len(requests))
+	}
+	for _, req := range requests {
+		if ua := req.Header.Get("User-Agent"); ua != "shop/1.0" {
+			t.Errorf("%s User-Agent = %q", req.Path, ua)
+		}
+	}
+	if q := requests[1].Query; q.Get("lang") != "de" || q.Get("currency") != "EUR" {
+		t.Errorf("default query = %v", q)
+	}
+	if q := requests[2].Query; q.Get("currency") != "GBP" {
+		t.Errorf("explicit currency query = %v", q)
+	}
+
+	logged := log.String()
+	if strings.Count(logged, "\n") != 3 || !strings.Contains(logged, EndpointGetProductPrice) {
+		t.Errorf("log = %q", logged)
+	}
+	if strings.Contains(logged, "app-secret") || strings.Contains(logged, "secret-token") {
+		t.Errorf("log leaks credentials: %q", logged)
+	}
+}
+
+func TestSandboxBaseURL(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, defaultURL},
+		{"sandbox", []Option{WithSandbox()}, sandboxURL},
+		{"sandbox with base URL", []Option{WithBaseURL("http://localhost:8080"), WithSandbox()}, "http://localhost:8080"},
+	}
+	for _, tt := range tests {
+		if got := NewClient("id", "secret", tt.opts...).(client).BaseURL; got != tt.want {
+			t.Errorf("%s: BaseURL = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidOrder      = errors.New("banggood: invalid order")
+	ErrSandboxProduction = errors.New("banggood: sandbox client is pointed at the production API")
+)
+
+// Validate checks that r carries everything Banggood needs to import it.
+// Errors wrap ErrInvalidOrder.
+func (r ImportOrderRequest) Validate() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"sale_record_id", r.SaleRecordID},
+		{"delivery_name", r.DeliveryName},
+		{"delivery_country", r.DeliveryCountry},
+		{"delivery_city", r.DeliveryCity},
+		{"delivery_street_address", r.DeliveryStreetAddress},
+		{"delivery_postcode", r.DeliveryPostcode},
+		{"delivery_telephone", r.DeliveryTelephone},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("%w: %s is required", ErrInvalidOrder, field.name)
+		}
+	}
+
+	if len(r.ProductList) == 0 {
+		return fmt.Errorf("%w: product_list is empty", ErrInvalidOrder)
+	}
+	if r.ProductTotal != len(r.ProductList) {
+		return fmt.Errorf("%w: product_total is %d but product_list has %d entries", ErrInvalidOrder, r.ProductTotal, len(r.ProductList))
+	}
+	for i, p := range r.ProductList {
+		if p.ProductID == "" {
+			return fmt.Errorf("%w: product_list[%d]: product_id is required", ErrInvalidOrder, i)
+		}
+		if p.ShipmethodCode == "" {
+			return fmt.Errorf("%w: product_list[%d]: shipmethod_code is required", ErrInvalidOrder, i)
+		}
+		quantity, err := strconv.Atoi(strings.TrimSpace(p.Quantity))
+		if err != nil || quantity <= 0 {
+			return fmt.Errorf("%w: product_list[%d]: quantity %q must be a positive integer", ErrInvalidOrder, i, p.Quantity)
+		}
+	}
+	return nil
+}
+
+func (c client) ImportOrder(ctx context.Context, order ImportOrderRequest) (ImportOrderResponse, error) {
+	if c.Sandbox && strings.TrimRight(c.BaseURL, "/") == defaultURL {
+		return ImportOrderResponse{}, ErrSandboxProduction
+	}
+	if order.AccessToken == "" {
+		return ImportOrderResponse{}, ErrEmptyAccessToken
+	}
+	if err := order.Validate(); err != nil {
+		return ImportOrderResponse{}, err
+	}
+	if order.Language == "" {
+		order.Language = c.Language
+	}
+	order.Currency = c.currency(order.Currency)
+
+	body, err := json.Marshal(order)
+	if err != nil {
+		return ImportOrderResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.importOrderURL(), bytes.NewReader(body))
+	if err != nil {
+		return ImportOrderResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if order.IdempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, order.IdempotencyKey)
+	}
+
+	var data ImportOrderResponse
+	err = c.send(EndpointImportOrder, req, &data)
+	return data, err
+}
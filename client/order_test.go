@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// stubRequest is a request received by an apiStub.
+type stubRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// apiStub is an API server that answers every request with respond and
+// records what it received.
+type apiStub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []stubRequest
+}
+
+func newAPIStub(t *testing.T, respond func(r *http.Request) (status int, body string)) *apiStub {
+	t.Helper()
+	s := &apiStub{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, stubRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: body})
+		s.mu.Unlock()
+		status, res := respond(r)
+		w.WriteHeader(status)
+		w.Write([]byte(res))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client returns a client for s. It does not retry, so the responses the
+// stub gives are the ones a test sees.
+func (s *apiStub) client() client {
+	return NewClient("id", "secret", WithBaseURL(s.URL), WithHTTPClient(s.Client()), WithRetryPolicy(RetryPolicy{})).(client)
+}
+
+func (s *apiStub) received() []stubRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubRequest(nil), s.requests...)
+}
+
+func validOrder() ImportOrderRequest {
+	return ImportOrderRequest{
+		SaleRecordID:          "SR-1",
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          1,
+		ProductList: []ImportOrderProduct{
+			{ProductID: "1000000", Quantity: "1", ShipmethodCode: "airmail"},
+		},
+	}
+}
+
+func TestImportOrderValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *ImportOrderRequest)
+	}{
+		{"missing sale record", func(o *ImportOrderRequest) { o.SaleRecordID = "" }},
+		{"blank postcode", func(o *ImportOrderRequest) { o.DeliveryPostcode = "  " }},
+		{"no lines", func(o *ImportOrderRequest) { o.ProductList, o.ProductTotal = nil, 0 }},
+		{"wrong total", func(o *ImportOrderRequest) { o.ProductTotal = 2 }},
+		{"missing product", func(o *ImportOrderRequest) { o.ProductList[0].ProductID = "" }},
+		{"missing ship method", func(o *ImportOrderRequest) { o.ProductList[0].ShipmethodCode = "" }},
+		{"zero quantity", func(o *ImportOrderRequest) { o.ProductList[0].Quantity = "0" }},
+		{"text quantity", func(o *ImportOrderRequest) { o.ProductList[0].Quantity = "two" }},
+	}
+	for _, tt := range tests {
+		o := validOrder()
+		tt.modify(&o)
+		if err := o.Validate(); !errors.Is(err, ErrInvalidOrder) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidOrder", tt.name, err)
+		}
+	}
+	if err := validOrder().Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestImportOrder(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		return http.StatusOK, `{"code":"0","sale_record_id":"SR-1","success_total":"0","failure_total":"1",
+			"failure_list":[{"product_id":"1000000","error_desc":"invalid shipmethod_code"}]}`
+	})
+	order := validOrder()
+	order.AccessToken = "token"
+	res, err := stub.client().ImportOrder(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SaleRecordID != "SR-1" || res.FailureTotal != "1" || res.FailureList[0].ErrorDescription != "invalid shipmethod_code" {
+		t.Errorf("ImportOrder = %+v", res)
+	}
+
+	requests := stub.received()
+	if len(requests) != 1 {
+		t.Fatalf("server received %d requests", len(requests))
+	}
+	req := requests[0]
+	if req.Method != http.MethodPost || req.Path != "/importOrder" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %s (%s)", req.Method, req.Path, req.Header.Get("Content-Type"))
+	}
+	var sent ImportOrderRequest
+	if err := json.Unmarshal(req.Body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.AccessToken != "token" || sent.Language != "en" || sent.SaleRecordID != "SR-1" || len(sent.ProductList) != 1 {
+		t.Errorf("sent %+v", sent)
+	}
+}
+
+func TestImportOrderAPIError(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		return http.StatusOK, `{"code":12001,"msg":"delivery_country is invalid"}`
+	})
+	order := validOrder()
+	order.AccessToken = "token"
+	if _, err := stub.client().ImportOrder(context.Background(), order); !errors.Is(err, ErrBadParameter) {
+		t.Errorf("ImportOrder = %v, want ErrBadParameter", err)
+	}
+}
+
+func TestImportOrderRejectedLocally(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		return http.StatusOK, `{"code":0}`
+	})
+	c := stub.client()
+	ctx := context.Background()
+
+	if _, err := c.ImportOrder(ctx, validOrder()); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Errorf("ImportOrder without token = %v, want ErrEmptyAccessToken", err)
+	}
+	invalid := validOrder()
+	invalid.AccessToken = "token"
+	invalid.ProductTotal = 3
+	if _, err := c.ImportOrder(ctx, invalid); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("invalid ImportOrder = %v, want ErrInvalidOrder", err)
+	}
+	if n := len(stub.received()); n != 0 {
+		t.Errorf("server received %d requests, want 0", n)
+	}
+}
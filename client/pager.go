@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// Done is returned by iterators when there are no more items.
+	Done = errors.New("banggood: no more items in iterator")
+)
+
+// Progress reports how far an iterator has got, so callers can tell how
+// much was read before a failure or cancellation.
+type Progress struct {
+	Page  Page
+	Pages int
+	Items int
+}
+
+// pageFunc fetches one page and returns its metadata and item count.
+type pageFunc func(ctx context.Context, page int) (Page, int, error)
+
+type pager struct {
+	ctx      context.Context
+	fetch    pageFunc
+	next     int
+	done     bool
+	err      error
+	progress Progress
+}
+
+func newPager(ctx context.Context, fetch pageFunc) pager {
+	return pager{
+		ctx:   ctx,
+		fetch: fetch,
+		next:  pageFrom,
+	}
+}
+
+// advance fetches the next page. It returns Done once the last page has
+// been fetched and sticks to the first error it sees.
+func (p *pager) advance() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.done {
+		return Done
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return err
+	}
+
+	requested := p.next
+	page, count, err := p.fetch(p.ctx, requested)
+	if err != nil {
+		p.err = err
+		return err
+	}
+	p.progress.Page = page
+	p.progress.Pages++
+	p.next++
+
+	// Stop on an empty page, a missing page total, or once the reported
+	// total is reached. Comparing against the requested page also guards
+	// against servers that echo a stale or zero page number.
+	if count == 0 || page.PageTotal <= 0 || requested >= page.PageTotal || page.PageNumber >= page.PageTotal {
+		p.done = true
+	}
+	return nil
+}
+
+func (p *pager) Progress() Progress {
+	return p.progress
+}
+
+type CategoryIterator struct {
+	pager
+	buf []Category
+}
+
+func newCategoryIterator(ctx context.Context, list func(ctx context.Context, page int) (GetCategoryListResponse, error)) *CategoryIterator {
+	it := &CategoryIterator{}
+	it.pager = newPager(ctx, func(ctx context.Context, page int) (Page, int, error) {
+		res, err := list(ctx, page)
+		it.buf = res.CategoryList
+		return res.Page, len(res.CategoryList), err
+	})
+	return it
+}
+
+func (it *CategoryIterator) Next() (Category, error) {
+	for len(it.buf) == 0 {
+		if err := it.advance(); err != nil {
+			return Category{}, err
+		}
+	}
+	item := it.buf[0]
+	it.buf = it.buf[1:]
+	it.progress.Items++
+	return item, nil
+}
+
+// All drains the iterator. On failure it returns the items read so far
+// together with the error.
+func (it *CategoryIterator) All() ([]Category, error) {
+	var items []Category
+	for {
+		item, err := it.Next()
+		if err == Done {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
+
+type ProductIterator struct {
+	pager
+	buf []Product
+}
+
+func newProductIterator(ctx context.Context, list func(ctx context.Context, page int) (GetProductListResponse, error)) *ProductIterator {
+	it := &ProductIterator{}
+	it.pager = newPager(ctx, func(ctx context.Context, page int) (Page, int, error) {
+		res, err := list(ctx, page)
+		it.buf = res.ProductList
+		return res.Page, len(res.ProductList), err
+	})
+	return it
+}
+
+func (it *ProductIterator) Next() (Product, error) {
+	for len(it.buf) == 0 {
+		if err := it.advance(); err != nil {
+			return Product{}, err
+		}
+	}
+	item := it.buf[0]
+	it.buf = it.buf[1:]
+	it.progress.Items++
+	return item, nil
+}
+
+func (it *ProductIterator) All() ([]Product, error) {
+	var items []Product
+	for {
+		item, err := it.Next()
+		if err == Done {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
+
+type ProductUpdateIterator struct {
+	pager
+	buf []ProductUpdate
+}
+
+func newProductUpdateIterator(ctx context.Context, list func(ctx context.Context, page int) (GetProductUpdateListResponse, error)) *ProductUpdateIterator {
+	it := &ProductUpdateIterator{}
+	it.pager = newPager(ctx, func(ctx context.Context, page int) (Page, int, error) {
+		res, err := list(ctx, page)
+		it.buf = res.UpdateProductList
+		return res.Page, len(res.UpdateProductList), err
+	})
+	return it
+}
+
+func (it *ProductUpdateIterator) Next() (ProductUpdate, error) {
+	for len(it.buf) == 0 {
+		if err := it.advance(); err != nil {
+			return ProductUpdate{}, err
+		}
+	}
+	item := it.buf[0]
+	it.buf = it.buf[1:]
+	it.progress.Items++
+	return item, nil
+}
+
+func (it *ProductUpdateIterator) All() ([]ProductUpdate, error) {
+	var items []ProductUpdate
+	for {
+		item, err := it.Next()
+		if err == Done {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
+
+type LimitPriceBrandIterator struct {
+	pager
+	buf []LimitPriceBrand
+}
+
+func newLimitPriceBrandIterator(ctx context.Context, list func(ctx context.Context, page int) (GetLimitPriceBrandResponse, error)) *LimitPriceBrandIterator {
+	it := &LimitPriceBrandIterator{}
+	it.pager = newPager(ctx, func(ctx context.Context, page int) (Page, int, error) {
+		res, err := list(ctx, page)
+		it.buf = res.BrandList
+		return res.Page, len(res.BrandList), err
+	})
+	return it
+}
+
+func (it *LimitPriceBrandIterator) Next() (LimitPriceBrand, error) {
+	for len(it.buf) == 0 {
+		if err := it.advance(); err != nil {
+			return LimitPriceBrand{}, err
+		}
+	}
+	item := it.buf[0]
+	it.buf = it.buf[1:]
+	it.progress.Items++
+	return item, nil
+}
+
+func (it *LimitPriceBrandIterator) All() ([]LimitPriceBrand, error) {
+	var items []LimitPriceBrand
+	for {
+		item, err := it.Next()
+		if err == Done {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
+
+type BrandLimitPriceIterator struct {
+	pager
+	buf []BrandLimitPrice
+}
+
+func newBrandLimitPriceIterator(ctx context.Context, list func(ctx context.Context, page int) (GetBrandLimitPriceListResponse, error)) *BrandLimitPriceIterator {
+	it := &BrandLimitPriceIterator{}
+	it.pager = newPager(ctx, func(ctx context.Context, page int) (Page, int, error) {
+		res, err := list(ctx, page)
+		it.buf = res.ProductList
+		return res.Page, len(res.ProductList), err
+	})
+	return it
+}
+
+func (it *BrandLimitPriceIterator) Next() (BrandLimitPrice, error) {
+	for len(it.buf) == 0 {
+		if err := it.advance(); err != nil {
+			return BrandLimitPrice{}, err
+		}
+	}
+	item := it.buf[0]
+	it.buf = it.buf[1:]
+	it.progress.Items++
+	return item, nil
+}
+
+func (it *BrandLimitPriceIterator) All() ([]BrandLimitPrice, error) {
+	var items []BrandLimitPrice
+	for {
+		item, err := it.Next()
+		if err == Done {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+}
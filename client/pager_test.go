@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+const stubPageSize = 10
+
+// newPagedStub serves total items per endpoint in pages of stubPageSize.
+// Every item has a single id field named key, numbered from zero. fail,
+// when set, may replace the response for a page.
+func newPagedStub(t *testing.T, list, key string, total int, fail func(page int) string) *apiStub {
+	t.Helper()
+	return newAPIStub(t, func(r *http.Request) (int, string) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if fail != nil {
+			if res := fail(page); res != "" {
+				return http.StatusOK, res
+			}
+		}
+		var items []map[string]string
+		for i := (page - 1) * stubPageSize; i < page*stubPageSize && i < total; i++ {
+			items = append(items, map[string]string{key: strconv.Itoa(i)})
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"code":       0,
+			"page":       page,
+			"page_total": (total + stubPageSize - 1) / stubPageSize,
+			"page_size":  stubPageSize,
+			list:         items,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		return http.StatusOK, string(body)
+	})
+}
+
+func (s *apiStub) managedClient() ManagedClient {
+	return NewManagedClient(s.client(), StaticTokenSource("token"))
+}
+
+func TestProductIterator(t *testing.T) {
+	stub := newPagedStub(t, "product_list", "product_id", 24, nil)
+	it := stub.managedClient().Products(context.Background(), "25", nil, nil, nil, nil)
+
+	products, err := it.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 24 {
+		t.Fatalf("read %d products, want 24", len(products))
+	}
+	for i, p := range products {
+		if p.ProductID != strconv.Itoa(i) {
+			t.Errorf("product %d = %s", i, p.ProductID)
+		}
+	}
+	if p := it.Progress(); p.Pages != 3 || p.Items != 24 || p.Page.PageTotal != 3 {
+		t.Errorf("Progress = %+v", p)
+	}
+	if _, err := it.Next(); err != Done {
+		t.Errorf("Next after the last page = %v, want Done", err)
+	}
+	requests := stub.received()
+	if len(requests) != 3 {
+		t.Fatalf("server received %d requests, want 3", len(requests))
+	}
+	for i, req := range requests {
+		if req.Query.Get("page") != strconv.Itoa(i+1) || req.Query.Get("cat_id") != "25" {
+			t.Errorf("request %d query = %v", i, req.Query)
+		}
+	}
+}
+
+func TestIteratorStopsOnError(t *testing.T) {
+	stub := newPagedStub(t, "product_list", "product_id", 30, func(page int) string {
+		if page == 2 {
+			return `{"code":13001,"msg":"slow down"}`
+		}
+		return ""
+	})
+	it := stub.managedClient().Products(context.Background(), "", nil, nil, nil, nil)
+
+	products, err := it.All()
+	if !errors.Is(err, ErrRateLimited) || len(products) != 10 {
+		t.Fatalf("All = %d products, %v", len(products), err)
+	}
+	if _, err := it.Next(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Next after an error = %v, want the same error", err)
+	}
+	if p := it.Progress(); p.Pages != 1 || p.Items != 10 {
+		t.Errorf("Progress = %+v", p)
+	}
+	if n := len(stub.received()); n != 2 {
+		t.Errorf("server received %d requests, want 2", n)
+	}
+}
+
+func TestIteratorHonoursContext(t *testing.T) {
+	stub := newPagedStub(t, "cat_list", "cat_id", 30, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	it := stub.managedClient().Categories(ctx)
+
+	if _, err := it.Next(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := it.All(); !errors.Is(err, context.Canceled) {
+		t.Errorf("All after cancel = %v, want context.Canceled", err)
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestIteratorStopsWithoutPageTotal(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		return http.StatusOK, `{"code":0,"page":0,"cat_list":[{"cat_id":"1"}]}`
+	})
+	categories, err := stub.managedClient().GetAllCategories(context.Background())
+	if err != nil || len(categories) != 1 {
+		t.Errorf("GetAllCategories = %d, %v", len(categories), err)
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestPagedEndpoints(t *testing.T) {
+	ctx := context.Background()
+
+	categories, err := newPagedStub(t, "cat_list", "cat_id", 12, nil).managedClient().GetAllCategories(ctx)
+	if err != nil || len(categories) != 12 {
+		t.Errorf("GetAllCategories = %d, %v", len(categories), err)
+	}
+	brands, err := newPagedStub(t, "brand_list", "brand_id", 3, nil).managedClient().GetAllLimitPriceBrands(ctx)
+	if err != nil || len(brands) != 3 {
+		t.Errorf("GetAllLimitPriceBrands = %d, %v", len(brands), err)
+	}
+	prices, err := newPagedStub(t, "product_list", "product_id", 21, nil).managedClient().GetAllBrandLimitPrices(ctx, "7")
+	if err != nil || len(prices) != 21 {
+		t.Errorf("GetAllBrandLimitPrices = %d, %v", len(prices), err)
+	}
+	updates, err := newPagedStub(t, "update_product_list", "product_id", 10, nil).managedClient().GetAllProductUpdates(ctx, 5)
+	if err != nil || len(updates) != 10 {
+		t.Errorf("GetAllProductUpdates = %d, %v", len(updates), err)
+	}
+}
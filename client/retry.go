@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// RetryPolicy controls how failed calls are retried. GET endpoints are
+// always eligible; ImportOrder is only retried when the request carries an
+// IdempotencyKey. NewClient uses DefaultRetryPolicy; the zero value disables
+// retries.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomises each delay by up to this fraction in either direction.
+	Jitter float64
+	// RetryableCodes are Banggood response codes worth retrying.
+	RetryableCodes []int
+	// RetryableStatus are HTTP status codes worth retrying.
+	RetryableStatus []int
+}
+
+var (
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts:     4,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableCodes:  []int{CodeRateLimited, CodeSystemBusy, CodeRequestTooFrequent},
+		RetryableStatus: []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+)
+
+// WithRetryPolicy replaces DefaultRetryPolicy. WithRetryPolicy(RetryPolicy{})
+// turns retries off.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *client) {
+		c.Retry = p
+	}
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable reports whether err is worth another attempt.
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return transportError(err)
+	}
+	for _, code := range p.RetryableCodes {
+		if apiErr.Code == code {
+			return true
+		}
+	}
+	for _, status := range p.RetryableStatus {
+		if apiErr.HTTPStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+// transportError reports whether err came from the network rather than from
+// a response that would fail the same way again, such as malformed JSON.
+func transportError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// backoff returns the delay before the given retry, counting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func idempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Header.Get(idempotencyKeyHeader) != ""
+}
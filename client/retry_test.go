@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	RetryableCodes:  []int{CodeSystemBusy},
+	RetryableStatus: []int{http.StatusServiceUnavailable},
+}
+
+// flakyTransport fails the first n requests with a network error.
+type flakyTransport struct {
+	next http.RoundTripper
+
+	mu sync.Mutex
+	n  int
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	fail := f.n > 0
+	f.n--
+	f.mu.Unlock()
+	if fail {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return f.next.RoundTrip(req)
+}
+
+func flaky(s *apiStub, n int) Option {
+	return WithHTTPClient(&http.Client{Transport: &flakyTransport{next: s.Client().Transport, n: n}})
+}
+
+// failing answers the first n requests with status and body, and later
+// ones with ok.
+func failing(n, status int, body, ok string) func(r *http.Request) (int, string) {
+	var mu sync.Mutex
+	return func(r *http.Request) (int, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if n > 0 {
+			n--
+			return status, body
+		}
+		return http.StatusOK, ok
+	}
+}
+
+const countriesBody = `{"code":0,"countries":[{"country_id":1,"country_name":"Germany"}]}`
+
+func TestRetryTransportErrors(t *testing.T) {
+	stub := newAPIStub(t, failing(0, 0, "", countriesBody))
+
+	c := NewClient("id", "secret", WithBaseURL(stub.URL), WithRetryPolicy(testRetryPolicy), flaky(stub, 2))
+	if _, err := c.GetCountries(context.Background(), "token"); err != nil {
+		t.Fatalf("GetCountries = %v", err)
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("getCountries reached the server %d times, want 1", n)
+	}
+
+	c = NewClient("id", "secret", WithBaseURL(stub.URL), WithRetryPolicy(testRetryPolicy), flaky(stub, 3))
+	_, err := c.GetCountries(context.Background(), "token")
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		t.Errorf("GetCountries after %d failures = %v, want the network error", testRetryPolicy.MaxAttempts, err)
+	}
+}
+
+func TestRetryByDefault(t *testing.T) {
+	stub := newAPIStub(t, failing(0, 0, "", `{"code":0,"access_token":"token","expires_in":7200}`))
+	ctx := context.Background()
+
+	c := NewClient("id", "secret", WithBaseURL(stub.URL), flaky(stub, 1))
+	if _, err := c.GetAccessToken(ctx); err != nil {
+		t.Errorf("GetAccessToken with the default policy = %v", err)
+	}
+	c = NewClient("id", "secret", WithBaseURL(stub.URL), flaky(stub, 1), WithRetryPolicy(RetryPolicy{}))
+	if _, err := c.GetAccessToken(ctx); err == nil {
+		t.Error("GetAccessToken with retries turned off succeeded")
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestRetryResponses(t *testing.T) {
+	busy := `{"code":13002,"msg":"system busy"}`
+	tests := []struct {
+		name     string
+		respond  func(r *http.Request) (int, string)
+		requests int
+		want     error
+	}{
+		{"retryable code", failing(2, http.StatusOK, busy, countriesBody), 3, nil},
+		{"retryable status", failing(2, http.StatusServiceUnavailable, `{"code":12001}`, countriesBody), 3, nil},
+		{"attempts exhausted", failing(3, http.StatusOK, busy, countriesBody), 3, ErrRateLimited},
+		{"not retryable", failing(1, http.StatusOK, `{"code":12001}`, countriesBody), 1, ErrBadParameter},
+	}
+	for _, tt := range tests {
+		stub := newAPIStub(t, tt.respond)
+		c := NewClient("id", "secret", WithBaseURL(stub.URL), WithHTTPClient(stub.Client()), WithRetryPolicy(testRetryPolicy))
+
+		res, err := c.GetCountries(context.Background(), "token")
+		if !errors.Is(err, tt.want) || (err != nil) != (tt.want != nil) {
+			t.Errorf("%s: GetCountries = %v, want %v", tt.name, err, tt.want)
+		}
+		if err == nil && len(res.Countries) == 0 {
+			t.Errorf("%s: retried response is empty", tt.name)
+		}
+		if n := len(stub.received()); n != tt.requests {
+			t.Errorf("%s: getCountries called %d times, want %d", tt.name, n, tt.requests)
+		}
+	}
+}
+
+func TestRetryMalformedResponse(t *testing.T) {
+	stub := newAPIStub(t, failing(0, 0, "", `{"code":0,"countries":`))
+	c := NewClient("id", "secret", WithBaseURL(stub.URL), WithHTTPClient(stub.Client()), WithRetryPolicy(testRetryPolicy))
+
+	if _, err := c.GetCountries(context.Background(), "token"); err == nil {
+		t.Fatal("GetCountries decoded a truncated body")
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("malformed response requested %d times, want 1", n)
+	}
+}
+
+func TestRetryImportOrder(t *testing.T) {
+	busy := `{"code":13002,"msg":"system busy"}`
+	stub := newAPIStub(t, failing(2, http.StatusOK, busy, `{"code":"0","sale_record_id":"SR-1"}`))
+	c := NewClient("id", "secret", WithBaseURL(stub.URL), WithHTTPClient(stub.Client()), WithRetryPolicy(testRetryPolicy))
+	ctx := context.Background()
+	order := validOrder()
+	order.AccessToken = "token"
+
+	if _, err := c.ImportOrder(ctx, order); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("ImportOrder without a key = %v, want ErrRateLimited", err)
+	}
+	if n := len(stub.received()); n != 1 {
+		t.Errorf("importOrder without a key called %d times, want 1", n)
+	}
+
+	order.IdempotencyKey = "SR-1"
+	if _, err := c.ImportOrder(ctx, order); err != nil {
+		t.Errorf("ImportOrder with a key = %v", err)
+	}
+	requests := stub.received()
+	if len(requests) != 3 {
+		t.Fatalf("importOrder called %d times in total, want 3", len(requests))
+	}
+	if key := requests[2].Header.Get(idempotencyKeyHeader); key != "SR-1" {
+		t.Errorf("Idempotency-Key = %q", key)
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSandboxMarksEveryRequest(t *testing.T) {
+	stub := newAPIStub(t, func(r *http.Request) (int, string) {
+		switch r.URL.Path {
+		case "/" + EndpointGetAccessToken:
+			return http.StatusOK, `{"code":0,"access_token":"token","expires_in":7200}`
+		case "/" + EndpointImportOrder:
+			return http.StatusOK, `{"code":"0","sale_record_id":"SR-1"}`
+		}
+		return http.StatusOK, `{"code":0}`
+	})
+	c := NewClient("id", "secret", WithSandbox(), WithBaseURL(stub.URL), WithHTTPClient(stub.Client()))
+	m := NewManagedClient(c, NewTokenSource(c))
+	ctx := context.Background()
+
+	if !m.IsSandbox() {
+		t.Error("IsSandbox = false")
+	}
+	if _, err := m.GetCountries(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ImportOrder(ctx, validOrder()); err != nil {
+		t.Fatal(err)
+	}
+	requests := stub.received()
+	if len(requests) != 3 {
+		t.Fatalf("server received %d requests, want token, countries and order", len(requests))
+	}
+	for _, req := range requests {
+		if req.Query.Get("apiTest") != "1" {
+			t.Errorf("%s %s lacks apiTest=1", req.Method, req.Path)
+		}
+	}
+}
+
+func TestSandboxRefusesProductionOrders(t *testing.T) {
+	c := NewClient("id", "secret", WithSandbox(), WithBaseURL(defaultURL+"/"))
+	order := validOrder()
+	order.AccessToken = "token"
+	if _, err := c.ImportOrder(context.Background(), order); !errors.Is(err, ErrSandboxProduction) {
+		t.Errorf("ImportOrder = %v, want ErrSandboxProduction", err)
+	}
+
+	if !NewSandboxClient("id", "secret").IsSandbox() || NewDefaultClient("id", "secret").IsSandbox() {
+		t.Error("IsSandbox does not match the constructor")
+	}
+}
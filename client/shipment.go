@@ -0,0 +1,192 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidShipday = errors.New("banggood: invalid shipday")
+
+// DeliveryWindow is the delivery time of a ship method, e.g. 7-20 business
+// days.
+type DeliveryWindow struct {
+	MinDays int `json:"min_days"`
+	MaxDays int `json:"max_days"`
+	// BusinessDays reports that the days exclude weekends and holidays.
+	BusinessDays bool `json:"business_days"`
+}
+
+func (w DeliveryWindow) String() string {
+	kind := "days"
+	if w.BusinessDays {
+		kind = "business days"
+	}
+	if w.MinDays == w.MaxDays {
+		return fmt.Sprintf("%d %s", w.MinDays, kind)
+	}
+	return fmt.Sprintf("%d-%d %s", w.MinDays, w.MaxDays, kind)
+}
+
+var shipdayPattern = regexp.MustCompile(`(\d+)(?:\s*(?:-|~|to)\s*(\d+))?`)
+
+// ParseDeliveryWindow parses shipday values such as "7-20 business days",
+// "10~15 days" or "3 working days".
+func ParseDeliveryWindow(s string) (DeliveryWindow, error) {
+	match := shipdayPattern.FindStringSubmatch(s)
+	if match == nil {
+		return DeliveryWindow{}, fmt.Errorf("%w: %q", ErrInvalidShipday, s)
+	}
+	w := DeliveryWindow{}
+	w.MinDays, _ = strconv.Atoi(match[1])
+	w.MaxDays = w.MinDays
+	if match[2] != "" {
+		w.MaxDays, _ = strconv.Atoi(match[2])
+	}
+	if w.MaxDays < w.MinDays {
+		w.MinDays, w.MaxDays = w.MaxDays, w.MinDays
+	}
+	lower := strings.ToLower(s)
+	w.BusinessDays = strings.Contains(lower, "business") || strings.Contains(lower, "working")
+	return w, nil
+}
+
+// UnmarshalJSON also accepts the older single-method shape, with the
+// method's fields at the top level, as a list of one.
+func (r *GetShipmentsResponse) UnmarshalJSON(b []byte) error {
+	type plain GetShipmentsResponse
+	var v struct {
+		plain
+		ShipMethodCode string `json:"shipmethodcode"`
+		ShipMethodName string `json:"shipmethodname"`
+		Shipday        string `json:"shipday"`
+		Shipfee        Money  `json:"shipfee"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*r = GetShipmentsResponse(v.plain)
+	if len(r.ShipMethods) == 0 && v.ShipMethodCode != "" {
+		r.ShipMethods = ShipMethods{{
+			ShipMethodCode: v.ShipMethodCode,
+			ShipMethodName: v.ShipMethodName,
+			Shipday:        v.Shipday,
+			Shipfee:        v.Shipfee,
+		}}
+	}
+	return nil
+}
+
+// DeliveryWindow parses m.Shipday.
+func (m ShipMethod) DeliveryWindow() (DeliveryWindow, error) {
+	return ParseDeliveryWindow(m.Shipday)
+}
+
+var trackedMarkers = []string{"register", "track", "express", "expedited", "priority", "dhl", "fedex", "ups", "ems", "tnt"}
+
+// Tracked reports whether m comes with a tracking number. Banggood does not
+// flag this, so it is inferred from the method's code and name, e.g.
+// "Air Parcel Register" or "Expedited Shipping".
+func (m ShipMethod) Tracked() bool {
+	s := strings.ToLower(m.ShipMethodCode + " " + m.ShipMethodName)
+	for _, marker := range trackedMarkers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShipMethods is a list of ship methods with filters that can be chained,
+// e.g. methods.Tracked().Within(10).Cheapest().
+type ShipMethods []ShipMethod
+
+func (ms ShipMethods) filter(keep func(m ShipMethod) bool) ShipMethods {
+	var kept ShipMethods
+	for _, m := range ms {
+		if keep(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// Tracked returns the methods with tracking.
+func (ms ShipMethods) Tracked() ShipMethods {
+	return ms.filter(ShipMethod.Tracked)
+}
+
+// Within returns the methods that deliver within days, judged by the end
+// of their delivery window. Methods with an unparseable Shipday are
+// dropped.
+func (ms ShipMethods) Within(days int) ShipMethods {
+	return ms.filter(func(m ShipMethod) bool {
+		w, err := m.DeliveryWindow()
+		return err == nil && w.MaxDays <= days
+	})
+}
+
+func (ms ShipMethods) Lookup(code string) (ShipMethod, bool) {
+	for _, m := range ms {
+		if m.ShipMethodCode == code {
+			return m, true
+		}
+	}
+	return ShipMethod{}, false
+}
+
+// Cheapest returns the method with the lowest fee, preferring the faster
+// one on a tie. Like Fastest, it reports false if ms is empty or its fees
+// are in different currencies.
+func (ms ShipMethods) Cheapest() (ShipMethod, bool) {
+	return ms.best(func(a, b ShipMethod) bool {
+		if c := a.Shipfee.MustCmp(b.Shipfee); c != 0 {
+			return c < 0
+		}
+		return maxDays(a) < maxDays(b)
+	})
+}
+
+// Fastest returns the method with the earliest end of its delivery window,
+// preferring the cheaper one on a tie.
+func (ms ShipMethods) Fastest() (ShipMethod, bool) {
+	return ms.best(func(a, b ShipMethod) bool {
+		if da, db := maxDays(a), maxDays(b); da != db {
+			return da < db
+		}
+		return a.Shipfee.MustCmp(b.Shipfee) < 0
+	})
+}
+
+func (ms ShipMethods) best(less func(a, b ShipMethod) bool) (ShipMethod, bool) {
+	if len(ms) == 0 {
+		return ShipMethod{}, false
+	}
+	fees := make([]Money, len(ms))
+	for i, m := range ms {
+		fees[i] = m.Shipfee
+	}
+	if _, err := CheckCurrency(fees...); err != nil {
+		return ShipMethod{}, false
+	}
+	best := ms[0]
+	for _, m := range ms[1:] {
+		if less(m, best) {
+			best = m
+		}
+	}
+	return best, true
+}
+
+// maxDays sorts methods with an unknown delivery time last.
+func maxDays(m ShipMethod) int {
+	w, err := m.DeliveryWindow()
+	if err != nil {
+		return math.MaxInt32
+	}
+	return w.MaxDays
+}
@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func TestParseDeliveryWindow(t *testing.T) {
+	tests := []struct {
+		in   string
+		want client.DeliveryWindow
+		text string
+	}{
+		{"7-20 business days", client.DeliveryWindow{MinDays: 7, MaxDays: 20, BusinessDays: true}, "7-20 business days"},
+		{"10~15 days", client.DeliveryWindow{MinDays: 10, MaxDays: 15}, "10-15 days"},
+		{"3 Working Days", client.DeliveryWindow{MinDays: 3, MaxDays: 3, BusinessDays: true}, "3 business days"},
+		{"5 to 8 days", client.DeliveryWindow{MinDays: 5, MaxDays: 8}, "5-8 days"},
+		{"20 - 7 days", client.DeliveryWindow{MinDays: 7, MaxDays: 20}, "7-20 days"},
+	}
+	for _, tt := range tests {
+		got, err := client.ParseDeliveryWindow(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDeliveryWindow(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.text {
+			t.Errorf("%q String = %q, want %q", tt.in, got, tt.text)
+		}
+	}
+	for _, in := range []string{"", "soon"} {
+		if _, err := client.ParseDeliveryWindow(in); !errors.Is(err, client.ErrInvalidShipday) {
+			t.Errorf("ParseDeliveryWindow(%q) = %v, want ErrInvalidShipday", in, err)
+		}
+	}
+}
+
+func TestShipMethodTracked(t *testing.T) {
+	tests := []struct {
+		code, name string
+		want       bool
+	}{
+		{"airmail", "Air Parcel Register", true},
+		{"expeditedshipping", "Expedited Shipping", true},
+		{"dhl", "DHL", true},
+		{"airmail", "Air Mail", false},
+		{"standard", "Standard Shipping", false},
+	}
+	for _, tt := range tests {
+		m := client.ShipMethod{ShipMethodCode: tt.code, ShipMethodName: tt.name}
+		if m.Tracked() != tt.want {
+			t.Errorf("%s Tracked = %v, want %v", tt.name, !tt.want, tt.want)
+		}
+	}
+}
+
+func shipmentProduct() banggoodtest.Product {
+	return banggoodtest.Product{
+		Product: client.Product{ProductID: "P1"},
+		Shipments: []client.ShipMethod{
+			{ShipMethodCode: "airmail", ShipMethodName: "Air Mail", Shipday: "15-30 days", Shipfee: usd(0)},
+			{ShipMethodCode: "registered", ShipMethodName: "Air Parcel Register", Shipday: "10-20 business days", Shipfee: usd(350)},
+			{ShipMethodCode: "priority", ShipMethodName: "Priority Direct Mail", Shipday: "8-12 business days", Shipfee: usd(350)},
+			{ShipMethodCode: "expressshipping", ShipMethodName: "Expedited Shipping", Shipday: "5-8 business days", Shipfee: usd(1930)},
+			{ShipMethodCode: "mystery", ShipMethodName: "Tracked Mystery", Shipday: "ask us", Shipfee: usd(100)},
+		},
+	}
+}
+
+func TestShipMethods(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{shipmentProduct()}})
+	res, err := srv.ManagedClient().GetShipments(context.Background(), "P1", "CN", "Germany", "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := res.ShipMethods
+
+	if m, ok := methods.Cheapest(); !ok || m.ShipMethodCode != "airmail" {
+		t.Errorf("Cheapest = %s", m.ShipMethodCode)
+	}
+	if m, ok := methods.Fastest(); !ok || m.ShipMethodCode != "expressshipping" {
+		t.Errorf("Fastest = %s", m.ShipMethodCode)
+	}
+	tracked := methods.Tracked()
+	if len(tracked) != 4 {
+		t.Errorf("Tracked = %d methods, want 4", len(tracked))
+	}
+	if m, ok := tracked.Cheapest(); !ok || m.ShipMethodCode != "mystery" {
+		t.Errorf("Tracked().Cheapest = %s", m.ShipMethodCode)
+	}
+	if m, ok := tracked.Within(20).Cheapest(); !ok || m.ShipMethodCode != "priority" {
+		t.Errorf("Tracked().Within(20).Cheapest = %s, want the faster of the tie", m.ShipMethodCode)
+	}
+	if m, ok := tracked.Within(20).Fastest(); !ok || m.ShipMethodCode != "expressshipping" {
+		t.Errorf("Tracked().Within(20).Fastest = %s", m.ShipMethodCode)
+	}
+	if _, ok := methods.Within(4).Cheapest(); ok {
+		t.Error("Within(4) is not empty")
+	}
+	mixed := append(client.ShipMethods{{ShipMethodCode: "eur", Shipday: "1 day", Shipfee: usd(1).In("EUR")}}, methods...)
+	if _, ok := mixed.Cheapest(); ok {
+		t.Error("Cheapest compared fees in different currencies")
+	}
+	if _, ok := mixed.Fastest(); ok {
+		t.Error("Fastest compared fees in different currencies")
+	}
+	if m, ok := methods.Lookup("registered"); !ok || m.ShipMethodName != "Air Parcel Register" {
+		t.Errorf("Lookup = %+v, %v", m, ok)
+	}
+	if _, ok := methods.Lookup("teleport"); ok {
+		t.Error("Lookup found an unknown method")
+	}
+}
+
+func TestGetShipmentsErrors(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	p := shipmentProduct()
+	p.Shipments = nil
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{p}})
+	c := srv.ManagedClient()
+
+	if _, err := c.GetShipments(context.Background(), "P1", "CN", "Germany", "", "", 1); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetShipments to an unserved country = %v", err)
+	}
+	if _, err := c.GetShipments(context.Background(), "P1", "CN", "Germany", "", "", 0); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetShipments of nothing = %v", err)
+	}
+}
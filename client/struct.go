@@ -13,6 +13,10 @@ type GetProductPriceResponse struct {
 	TranslatedText string `json:"TranslatedText"`
 	Error          int    `json:"error"`
 	ErrorMessage   string `json:"errMsg"`
+	Currency       string `json:"currency"`
+	// ProductPrice is the unit price of the product with the requested
+	// POA from the requested warehouse.
+	ProductPrice Money `json:"product_price"`
 }
 
 type GetAccessTokenResponse struct {
@@ -37,13 +41,13 @@ type GetCategoryListResponse struct {
 }
 
 type Product struct {
-	ProductID       string `json:"product_id"`
-	CategoryID      int    `json:"cat_id"` //TODO: report error
-	ProductName     string `json:"product_name"`
-	Image           string `json:"img"`
-	MetaDescription string `json:"meta_desc"`
-	AddDate         string `json:"add_date"`
-	ModifyDate      string `json:"modify_date"`
+	ProductID       string       `json:"product_id"`
+	CategoryID      int          `json:"cat_id"` //TODO: report error
+	ProductName     string       `json:"product_name"`
+	Image           string       `json:"img"`
+	MetaDescription string       `json:"meta_desc"`
+	AddDate         BanggoodTime `json:"add_date"`
+	ModifyDate      BanggoodTime `json:"modify_date"`
 }
 
 type GetProductListResponse struct {
@@ -65,187 +69,236 @@ type Image struct {
 	Large      string `json:"large"`
 }
 
+type ProductOption struct {
+	OptionID     int           `json:"option_id"`
+	OptionName   string        `json:"option_name"`
+	OptionValues []OptionValue `json:"option_values"`
+}
+
+type OptionValue struct {
+	PoaID         string `json:"poa_id"`
+	PoaName       string `json:"poa_name"`
+	Poa           string `json:"poa"`
+	PoaPrice      Money  `json:"poa_price"`
+	SmallImage    string `json:"small_image"`
+	ViewImage     string `json:"view_image"`
+	LargeImage    string `json:"large_image"`
+	ListGridImage string `json:"list_grid_image"`
+}
+
+type ProductWarehouse struct {
+	Warehouse      string `json:"warehouse"`
+	WarehousePrice Money  `json:"warehouse_price"`
+}
+
 type GetProductInfoResponse struct {
-	PoaList []struct {
-		OptionID     int    `json:"option_id"`
-		OptionName   string `json:"option_name"`
-		OptionValues []struct {
-			PoaID         string `json:"poa_id"`
-			PoaName       string `json:"poa_name"`
-			Poa           string `json:"poa"`
-			PoaPrice      string `json:"poa_price"`
-			SmallImage    string `json:"small_image"`
-			ViewImage     string `json:"view_image"`
-			LargeImage    string `json:"large_image"`
-			ListGridImage string `json:"list_grid_image"`
-		} `json:"option_values"`
-	} `json:"poa_list"`
-	WarehouseList []struct {
-		Warehouse      string `json:"warehouse"`
-		WarehousePrice string `json:"warehouse_price"`
-	} `json:"warehouse_list"`
-	ImageList   []Image `json:"image_list"`
-	Description string  `json:"description"`
-	Code        int     `json:"code"`
-	Language    string  `json:"lang"`
-	Weight      float32 `json:"weight"`
-	ProductName string  `json:"product_name"`
+	PoaList       []ProductOption    `json:"poa_list"`
+	WarehouseList []ProductWarehouse `json:"warehouse_list"`
+	ImageList     []Image            `json:"image_list"`
+	Description   string             `json:"description"`
+	Code          int                `json:"code"`
+	Language      string             `json:"lang"`
+	Weight        float32            `json:"weight"`
+	ProductName   string             `json:"product_name"`
 }
 
 type GetShipmentsResponse struct {
-	Code           int    `json:"code"`
-	Currency       string `json:"currency"`
-	ShipMethodCode string `json:"shipmethodcode"`
-	ShipMethodName string `json:"shipmethodname"`
+	Code        int         `json:"code"`
+	Currency    string      `json:"currency"`
+	ShipMethods ShipMethods `json:"shipmethod_list"`
+}
+
+type ShipMethod struct {
+	ShipMethodCode string `json:"shipmethod_code"`
+	ShipMethodName string `json:"shipmethod_name"`
 	Shipday        string `json:"shipday"`
-	Shipfee        string `json:"shipfee"`
+	Shipfee        Money  `json:"shipfee"`
 }
 
 type ImportOrderRequest struct {
-	AccessToken            string `json:"access_token"`
-	SaleRecordID           string `json:"sale_record_id"`
+	AccessToken            string               `json:"access_token"`
+	SaleRecordID           string               `json:"sale_record_id"`
+	DeliveryName           string               `json:"delivery_name"`
+	DeliveryCountry        string               `json:"delivery_country"`
+	DeliveryState          string               `json:"delivery_state"`
+	DeliveryCity           string               `json:"delivery_city"`
+	DeliveryStreetAddress  string               `json:"delivery_street_address"`
+	DeliveryStreetAddress2 string               `json:"delivery_street_address2"`
+	DeliveryPostcode       string               `json:"delivery_postcode"`
+	DeliveryTelephone      string               `json:"delivery_telephone"`
+	ProductTotal           int                  `json:"product_total"`
+	ProductList            []ImportOrderProduct `json:"product_list"`
+	Language               string               `json:"lang"`
+	Currency               string               `json:"currency"`
+	// IdempotencyKey opts ImportOrder into the client's retry policy.
+	// It is sent as a header, never in the order body.
+	IdempotencyKey string `json:"-"`
+}
+
+type ImportOrderProduct struct {
+	ProductID      string `json:"product_id"`
+	PoaID          string `json:"poa_id"`
+	Warehouse      string `json:"warehouse"`
+	Quantity       string `json:"quantity"`
+	ShipmethodCode string `json:"shipmethod_code"`
+}
+
+type ImportOrderResponse struct {
+	SaleRecordID string               `json:"sale_record_id"`
+	ProductTotal string               `json:"product_total"`
+	SuccessTotal string               `json:"success_total"`
+	FailureTotal string               `json:"failure_total"`
+	FailureList  []ImportOrderFailure `json:"failure_list"`
+	Code         string               `json:"code"`
+}
+
+type ImportOrderFailure struct {
+	ProductID        string `json:"product_id"`
+	PoaID            string `json:"poa_id"`
+	Warehouse        string `json:"warehouse"`
+	Quantity         string `json:"quantity"`
+	ShipmethodCode   string `json:"shipmethod_code"`
+	ErrorDescription string `json:"error_desc"`
+}
+
+type GetOrderInfoResponse struct {
+	Code             int          `json:"code"`
+	SaleRecordIDList []SaleRecord `json:"sale_record_id_list"`
+}
+
+type SaleRecord struct {
+	SaleRecordID string     `json:"sale_record_id"`
+	OrderList    []Order    `json:"order_list"`
+	UserInfo     []UserInfo `json:"user_info"`
+}
+
+type Order struct {
+	OrderID          string         `json:"order_id"`
+	Status           string         `json:"status"`
+	TotalAmount      Money          `json:"total_amount"`
+	Currency         string         `json:"currency"`
+	ShipmethodCode   string         `json:"shipment_method"`
+	SubAmount        Money          `json:"sub_amount"`
+	DropShipDiscount Money          `json:"ds_discount"`
+	Shipfee          Money          `json:"shipfee"`
+	ShipInsurance    Money          `json:"ship_insurance"`
+	TariffInsurance  Money          `json:"tariff_insurance"`
+	ProductList      []OrderProduct `json:"product_list"`
+}
+
+type OrderProduct struct {
+	ProductID string   `json:"product_id"`
+	Warehouse []string `json:"warehouse"`
+	Quantity  int      `json:"quantity"`
+	PoaID     string   `json:"poa_id"`
+}
+
+type UserInfo struct {
 	DeliveryName           string `json:"delivery_name"`
 	DeliveryCountry        string `json:"delivery_country"`
 	DeliveryState          string `json:"delivery_state"`
 	DeliveryCity           string `json:"delivery_city"`
 	DeliveryStreetAddress  string `json:"delivery_street_address"`
-	DeliveryStreetAddress2 string `json:"delivery_street_address2"`
-	DeliveryPostcode       string `json:"delivery_postcode"`
-	DeliveryTelephone      string `json:"delivery_telephone"`
-	ProductTotal           int    `json:"product_total"`
-	ProductList            []struct {
-		ProductID      string `json:"product_id"`
-		PoaID          string `json:"poa_id"`
-		Quantity       string `json:"quantity"`
-		ShipmethodCode string `json:"shipmethod_code"`
-	} `json:"product_list"`
-	Language string `json:"lang"`
-	Currency string `json:"currency"`
+	DeliveryStreetAddress2 string `json:"delivery_steet_address2"`
 }
 
-type ImportOrderResponse struct {
-	SaleRecordID string `json:"sale_record_id"`
-	ProductTotal string `json:"product_total"`
-	SuccessTotal string `json:"success_total"`
-	FailureTotal string `json:"failure_total"`
-	FailureList  []struct {
-		ProductID        string `json:"product_id"`
-		PoaID            string `json:"poa_id"`
-		Warehouse        string `json:"warehouse"`
-		Quantity         string `json:"quantity"`
-		ShipmethodCode   string `json:"shipmethod_code"`
-		ErrorDescription string `json:"error_desc"`
-	} `json:"failure_list"`
-	Code string `json:"code"`
+type GetTrackInfoResponse struct {
+	TrackInfo []TrackEvent `json:"track_info"`
+	Code      string       `json:"code"`
 }
 
-type GetOrderInfoResponse struct {
-	Code             int `json:"code"`
-	SaleRecordIDList []struct {
-		SaleRecordID string `json:"sale_record_id"`
-		OrderList    []struct {
-			OrderID          string  `json:"order_id"`
-			Status           string  `json:"status"`
-			TotalAmount      float32 `json:"total_amount"`
-			Currency         string  `json:"currency"`
-			ShipmethodCode   string  `json:"shipment_method"`
-			SubAmount        float32 `json:"sub_amount"`
-			DropShipDiscount float32 `json:"ds_discount"`
-			Shipfee          float32 `json:"shipfee"`
-			ShipInsurance    float32 `json:"ship_insurance"`
-			TariffInsurance  float32 `json:"tariff_insurance"`
-			ProductList      []struct {
-				ProductID string   `json:"product_id"`
-				Warehouse []string `json:"warehouse"`
-				Quantity  int      `json:"quantity"`
-				PoaID     string   `json:"poa_id"`
-			} `json:"product_list"`
-		} `json:"order_list"`
-		UserInfo []struct {
-			DeliveryName           string `json:"delivery_name"`
-			DeliveryCountry        string `json:"delivery_country"`
-			DeliveryState          string `json:"delivery_state"`
-			DeliveryCity           string `json:"delivery_city"`
-			DeliveryStreetAddress  string `json:"delivery_street_address"`
-			DeliveryStreetAddress2 string `json:"delivery_steet_address2"`
-		} `json:"user_info"`
-	} `json:"sale_record_id_list"`
+type TrackEvent struct {
+	Event string `json:"event"`
+	Time  string `json:"time"`
 }
 
-type GetTrackInfoResponse struct {
-	TrackInfo []struct {
-		Event string `json:"event"`
-		Time  string `json:"time"`
-	} `json:"track_info"`
-	Code string `json:"code"`
+type GetOrderHistoryResponse struct {
+	OrderHistory []OrderStatus `json:"order_history"`
+	TrackNumber  string        `json:"track_number"`
+	Code         string        `json:"code"`
 }
 
-type GetOrderHistoryResponse struct {
-	OrderHistory []struct {
-		Status  string `json:"status"`
-		DateAdd string `json:"date_add"`
-	} `json:"order_history"`
-	TrackNumber string `json:"track_number"`
-	Code        string `json:"code"`
+type OrderStatus struct {
+	Status  string `json:"status"`
+	DateAdd string `json:"date_add"`
 }
 
 type GetCountriesResponse struct {
-	Countries []struct {
-		CountryID   int    `json:"country_id"`
-		CountryName string `json:"country_name"`
-	} `json:"countries"`
-	Code int `json:"code"`
+	Countries []Country `json:"countries"`
+	Code      int       `json:"code"`
+}
+
+type Country struct {
+	CountryID   int    `json:"country_id"`
+	CountryName string `json:"country_name"`
 }
 
 type GetStockResponse struct {
-	Stocks []struct {
-		Warehouse  string `json:"warehouse"`
-		StocksList []struct {
-			PoaID         int    `json:"poa_id"`
-			Poa           string `json:"poa"`
-			Stock         string `json:"stock"`
-			StocksMessage string `json:"stocks_msg"`
-		} `json:"stocks_list"`
-	} `json:"stocks"`
-	Code     string `json:"code"`
-	Language string `json:"lang"`
+	Stocks   []WarehouseStock `json:"stocks"`
+	Code     string           `json:"code"`
+	Language string           `json:"lang"`
+}
+
+type WarehouseStock struct {
+	Warehouse  string     `json:"warehouse"`
+	StocksList []PoaStock `json:"stocks_list"`
+}
+
+type PoaStock struct {
+	PoaID         int    `json:"poa_id"`
+	Poa           string `json:"poa"`
+	Stock         string `json:"stock"`
+	StocksMessage string `json:"stocks_msg"`
 }
 
 type GetProductUpdateListResponse struct {
 	Page
 
-	Code              int    `json:"code"`
-	ProductTotal      int    `json:"product_total"`
-	Language          string `json:"lang"`
-	UpdateProductList []struct {
-		ProductID  string `json:"product_id"`
-		State      int    `json:"state"`
-		ModifyDate string `json:"modify_date"`
-	} `json:"update_product_list"`
+	Code              int             `json:"code"`
+	ProductTotal      int             `json:"product_total"`
+	Language          string          `json:"lang"`
+	UpdateProductList []ProductUpdate `json:"update_product_list"`
+}
+
+// States reported in ProductUpdate.State.
+const (
+	ProductStateUpdated  = 1
+	ProductStateOffShelf = 2
+	ProductStateDeleted  = 3
+)
+
+type ProductUpdate struct {
+	ProductID  string       `json:"product_id"`
+	State      int          `json:"state"`
+	ModifyDate BanggoodTime `json:"modify_date"`
 }
 
 type GetBrandLimitPriceListResponse struct {
 	Page
 
-	Code         int    `json:"code"`
-	ProductTotal int    `json:"product_total"`
-	Language     string `json:"lang"`
-	ProductList  []struct {
-		ProductID  string `json:"product_id"`
-		Sku        string `json:"sku"`
-		Poa        string `json:"poa"`
-		LimitPrice string `json:"limit_price"`
-	} `json:"product_list"`
+	Code         int               `json:"code"`
+	ProductTotal int               `json:"product_total"`
+	Language     string            `json:"lang"`
+	ProductList  []BrandLimitPrice `json:"product_list"`
+}
+
+type BrandLimitPrice struct {
+	ProductID  string `json:"product_id"`
+	Sku        string `json:"sku"`
+	Poa        string `json:"poa"`
+	LimitPrice Money  `json:"limit_price"`
 }
 
 type GetLimitPriceBrandResponse struct {
 	Page
 
-	Code       int    `json:"code"`
-	BrandTotal int    `json:"brand_total"`
-	Language   string `json:"lang"`
-	BrandList  []struct {
-		BrandID string `json:"brand_id"`
-		Name    string `json:"name"`
-	} `json:"brand_list"`
+	Code       int               `json:"code"`
+	BrandTotal int               `json:"brand_total"`
+	Language   string            `json:"lang"`
+	BrandList  []LimitPriceBrand `json:"brand_list"`
+}
+
+type LimitPriceBrand struct {
+	BrandID string `json:"brand_id"`
+	Name    string `json:"name"`
 }
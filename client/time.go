@@ -0,0 +1,65 @@
+package client
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	// BanggoodLocation is the timezone Banggood uses for request filters and
+	// response timestamps (China Standard Time, no DST).
+	BanggoodLocation = time.FixedZone("CST", 8*60*60)
+)
+
+// BanggoodTime is a time.Time that marshals to and from the
+// "2006-01-02 15:04:05" format in Banggood's timezone.
+type BanggoodTime struct {
+	time.Time
+}
+
+func NewBanggoodTime(t time.Time) BanggoodTime {
+	return BanggoodTime{Time: t}
+}
+
+func ParseBanggoodTime(s string) (BanggoodTime, error) {
+	if s == "" || s == "0000-00-00 00:00:00" {
+		return BanggoodTime{}, nil
+	}
+	t, err := time.ParseInLocation(timeLayout, s, BanggoodLocation)
+	if err != nil {
+		return BanggoodTime{}, err
+	}
+	return BanggoodTime{Time: t}, nil
+}
+
+func (t BanggoodTime) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(BanggoodLocation).Format(timeLayout)
+}
+
+func (t BanggoodTime) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+func (t *BanggoodTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*t = BanggoodTime{}
+		return nil
+	}
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseBanggoodTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
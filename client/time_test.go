@@ -0,0 +1,57 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseBanggoodTime(t *testing.T) {
+	got, err := ParseBanggoodTime("2021-03-01 20:00:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseBanggoodTime = %v, want %v", got, want)
+	}
+	for _, zero := range []string{"", "0000-00-00 00:00:00"} {
+		if got, err := ParseBanggoodTime(zero); err != nil || !got.IsZero() {
+			t.Errorf("ParseBanggoodTime(%q) = %v, %v; want zero", zero, got, err)
+		}
+	}
+	if _, err := ParseBanggoodTime("2021-03-01T20:00:00Z"); err == nil {
+		t.Error("ParseBanggoodTime accepted RFC 3339")
+	}
+}
+
+func TestBanggoodTimeString(t *testing.T) {
+	utc := NewBanggoodTime(time.Date(2021, 12, 31, 20, 30, 0, 0, time.UTC))
+	if got := utc.String(); got != "2022-01-01 04:30:00" {
+		t.Errorf("String = %s", got)
+	}
+	if got := (BanggoodTime{}).String(); got != "" {
+		t.Errorf("zero String = %q", got)
+	}
+}
+
+func TestBanggoodTimeJSON(t *testing.T) {
+	var v struct {
+		A, B, C BanggoodTime
+	}
+	if err := json.Unmarshal([]byte(`{"A":"2021-03-01 20:00:00","B":"0000-00-00 00:00:00","C":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "2021-03-01 20:00:00" || !v.B.IsZero() || !v.C.IsZero() {
+		t.Errorf("decoded %+v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"A":"2021-03-01 20:00:00","B":"","C":""}`; string(b) != want {
+		t.Errorf("Marshal = %s, want %s", b, want)
+	}
+	if err := json.Unmarshal([]byte(`{"A":20210301}`), &v); err == nil {
+		t.Error("Unmarshal accepted a number")
+	}
+}
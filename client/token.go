@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshMargin is how long before the reported expiry a cached
+	// token is considered stale and refreshed.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+var (
+	ErrEmptyAccessToken = errors.New("banggood: empty access token in response")
+)
+
+// TokenSource supplies access tokens for API calls.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// NewTokenSource returns a TokenSource that obtains tokens through
+// c.GetAccessToken, caches them and refreshes them shortly before expiry.
+// It is safe for concurrent use.
+func NewTokenSource(c BanggoodClient) *CachedTokenSource {
+	return &CachedTokenSource{
+		client: c,
+		now:    time.Now,
+	}
+}
+
+// StaticTokenSource always returns the same token.
+type StaticTokenSource string
+
+func (s StaticTokenSource) Token(ctx context.Context) (string, error) {
+	if s == "" {
+		return "", ErrEmptyAccessToken
+	}
+	return string(s), nil
+}
+
+type CachedTokenSource struct {
+	client BanggoodClient
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (s *CachedTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Before(s.expiry) {
+		return s.token, nil
+	}
+
+	res, err := s.client.GetAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if res.AccessToken == "" {
+		return "", ErrEmptyAccessToken
+	}
+
+	lifetime := time.Duration(res.ExpiresIn) * time.Second
+	margin := tokenRefreshMargin
+	if lifetime <= 2*margin {
+		margin = lifetime / 2
+	}
+	s.token = res.AccessToken
+	s.expiry = s.now().Add(lifetime - margin)
+	return s.token, nil
+}
+
+// Invalidate drops the cached token so the next call to Token fetches a new one.
+func (s *CachedTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+	s.expiry = time.Time{}
+}
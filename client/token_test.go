@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// tokenClient hands out numbered tokens with a fixed lifetime, or empty
+// ones if empty is set.
+type tokenClient struct {
+	BanggoodClient
+	expiresIn int
+	empty     bool
+	issued    int
+}
+
+func (c *tokenClient) GetAccessToken(ctx context.Context) (GetAccessTokenResponse, error) {
+	c.issued++
+	res := GetAccessTokenResponse{ExpiresIn: c.expiresIn}
+	if !c.empty {
+		res.AccessToken = fmt.Sprintf("token-%d", c.issued)
+	}
+	return res, nil
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &tokenClient{expiresIn: 7200}
+	s := NewTokenSource(c)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if tok, err := s.Token(ctx); err != nil || tok != "token-1" {
+			t.Fatalf("Token = %q, %v", tok, err)
+		}
+	}
+	// Tokens are refreshed tokenRefreshMargin before they expire.
+	now = now.Add(2*time.Hour - tokenRefreshMargin)
+	if tok, _ := s.Token(ctx); tok != "token-2" || c.issued != 2 {
+		t.Errorf("Token near expiry = %q after %d requests", tok, c.issued)
+	}
+	s.Invalidate()
+	if tok, _ := s.Token(ctx); tok != "token-3" {
+		t.Errorf("Token after Invalidate = %q", tok)
+	}
+
+	// Short-lived tokens keep half their lifetime as margin.
+	short := NewTokenSource(&tokenClient{expiresIn: 60})
+	short.now = func() time.Time { return now }
+	if _, err := short.Token(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(30 * time.Second); !short.expiry.Equal(want) {
+		t.Errorf("expiry = %v, want %v", short.expiry, want)
+	}
+}
+
+func TestEmptyToken(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewTokenSource(&tokenClient{empty: true}).Token(ctx); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Errorf("CachedTokenSource = %v, want ErrEmptyAccessToken", err)
+	}
+	if _, err := StaticTokenSource("").Token(ctx); !errors.Is(err, ErrEmptyAccessToken) {
+		t.Errorf("StaticTokenSource(\"\") = %v, want ErrEmptyAccessToken", err)
+	}
+	if tok, err := StaticTokenSource("abc").Token(ctx); err != nil || tok != "abc" {
+		t.Errorf("StaticTokenSource = %q, %v", tok, err)
+	}
+}
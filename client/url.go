@@ -1,101 +1,188 @@
 package client
 
 import (
-	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"golang.org/x/text/language"
 )
 
-const (
-	timeFormat = "1998-05-22 12:00:00"
-)
-
 var (
 	en = language.English.String()
 )
 
-func optionalInt(key string, value *int) string {
+// query wraps url.Values with typed setters so every parameter is encoded
+// the same way regardless of endpoint.
+type query url.Values
+
+func (q query) set(key, value string) query {
+	url.Values(q).Set(key, value)
+	return q
+}
+
+func (q query) setInt(key string, value int) query {
+	return q.set(key, strconv.Itoa(value))
+}
+
+func (q query) optionalInt(key string, value *int) query {
 	if value == nil {
-		return ""
+		return q
 	}
-	return fmt.Sprintf("%s=%d&", key, *value)
+	return q.setInt(key, *value)
 }
 
-func optionalTime(key string, value *time.Time) string {
-	if value == nil {
-		return ""
+func (q query) optionalTime(key string, value *time.Time) query {
+	if value == nil || value.IsZero() {
+		return q
 	}
-	return fmt.Sprintf("%s=%s&", key, value.Format(timeFormat))
+	return q.set(key, NewBanggoodTime(*value).String())
 }
 
-func optionalString(key string, value *string) string {
+func (q query) optionalString(key string, value *string) query {
 	if value == nil {
-		return ""
+		return q
 	}
-	return fmt.Sprintf("%s=%s&", key, *value)
+	return q.set(key, *value)
+}
+
+func (c client) endpointURL(endpoint string, q query) string {
+	if c.Sandbox {
+		if q == nil {
+			q = query{}
+		}
+		q.set("apiTest", "1")
+	}
+	u := c.BaseURL + "/" + endpoint
+	if len(q) == 0 {
+		return u
+	}
+	return u + "?" + url.Values(q).Encode()
+}
+
+func (c client) tokenQuery(token string) query {
+	return query{}.set("access_token", token)
+}
+
+func (c client) langQuery(token string) query {
+	lang := c.Language
+	if lang == "" {
+		lang = en
+	}
+	return c.tokenQuery(token).set("lang", lang)
+}
+
+// redactURL hides credentials so request URLs can be logged.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	q := redacted.Query()
+	for _, key := range []string{"access_token", "app_id", "app_secret"} {
+		if q.Get(key) != "" {
+			q.Set(key, "REDACTED")
+		}
+	}
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
 }
 
 func (c client) translateURL(token, productID, poaID, warehouse, currency string) string {
-	return fmt.Sprintf("%s/product/Translate?access_token=%s&lang=%s&product_id=%s&poa_id=%s&warehouse=%s&currency=%s", c.BaseURL, token, en, productID, poaID, warehouse, currency)
+	return c.endpointURL(EndpointTranslate, c.langQuery(token).
+		set("product_id", productID).
+		set("poa_id", poaID).
+		set("warehouse", warehouse).
+		set("currency", c.currency(currency)))
 }
 
 func (c client) getProductPriceURL(token, productID, poaID, warehouse, currency string) string {
-	return fmt.Sprintf("%s/product/GetProductPrice?access_token=%s&lang=%s&product_id=%s&poa_id=%s&warehouse=%s&currency=%s", c.BaseURL, token, en, productID, poaID, warehouse, currency)
+	return c.endpointURL(EndpointGetProductPrice, c.langQuery(token).
+		set("product_id", productID).
+		set("poa_id", poaID).
+		set("warehouse", warehouse).
+		set("currency", c.currency(currency)))
 }
 
 func (c client) getAccessTokenURL() string {
-	return fmt.Sprintf("%s/getAccessToken?app_id=%s&app_secret=%s", c.BaseURL, c.AppID, c.AppSecret)
+	return c.endpointURL(EndpointGetAccessToken, query{}.
+		set("app_id", c.AppID).
+		set("app_secret", c.AppSecret))
 }
 
 func (c client) getCategoryListURL(token string, page *int) string {
-	return fmt.Sprintf("%s/category/getCategoryList?access_token=%s&lang=%s&%s", c.BaseURL, token, en, optionalInt("page", page))
+	return c.endpointURL(EndpointGetCategoryList, c.langQuery(token).
+		optionalInt("page", page))
 }
 
 func (c client) getProductListURL(token, categoryID string, addDateStart, addDateEnd, modifyDateStart, modifyDateEnd *time.Time, page *int) string {
-	return fmt.Sprintf("%s/product/getProductList?access_token=%s&lang=%s&cat_id=%s&%s%s%s%s%s", c.BaseURL, token, en, categoryID, optionalTime("add_date_start", addDateStart), optionalTime("add_date_end", addDateEnd), optionalTime("modify_date_start", modifyDateStart), optionalTime("modify_date_end", modifyDateEnd), optionalInt("page", page))
+	return c.endpointURL(EndpointGetProductList, c.langQuery(token).
+		set("cat_id", categoryID).
+		optionalTime("add_date_start", addDateStart).
+		optionalTime("add_date_end", addDateEnd).
+		optionalTime("modify_date_start", modifyDateStart).
+		optionalTime("modify_date_end", modifyDateEnd).
+		optionalInt("page", page))
 }
 
 func (c client) getProductInfoURL(token, productID string, currency *string) string {
-	return fmt.Sprintf("%s/product/getProductInfo?access_token=%s&lang=%s&product_id=%s&%s", c.BaseURL, token, en, productID, optionalString("currency", currency))
+	if currency == nil && c.Currency != "" {
+		currency = &c.Currency
+	}
+	return c.endpointURL(EndpointGetProductInfo, c.langQuery(token).
+		set("product_id", productID).
+		optionalString("currency", currency))
 }
 
 func (c client) getShipmentsURL(token, productID, warehouse, country, poaID, currency string, quantity int) string {
-	return fmt.Sprintf("%s/product/getShipments?access_token=%s&lang=%s&product_id=%s&warehouse=%s&country=%s&poa_id=%s&quantity=%s&currency=%s", c.BaseURL, token, en, productID, warehouse, country, poaID, quantity, currency)
+	return c.endpointURL(EndpointGetShipments, c.langQuery(token).
+		set("product_id", productID).
+		set("warehouse", warehouse).
+		set("country", country).
+		set("poa_id", poaID).
+		setInt("quantity", quantity).
+		set("currency", c.currency(currency)))
 }
 
 func (c client) importOrderURL() string {
-	return fmt.Sprintf("%s/importOrder", c.BaseURL)
+	return c.endpointURL(EndpointImportOrder, nil)
 }
 
 func (c client) getOrderInfoURL(token, saleRecordID string) string {
-	return fmt.Sprintf("%s/order/getOrderInfo?access_token=%s&lang=%s&sale_record_id=%s&", c.BaseURL, token, en, saleRecordID)
+	return c.endpointURL(EndpointGetOrderInfo, c.langQuery(token).
+		set("sale_record_id", saleRecordID))
 }
 
 func (c client) getTrackInfoURL(token, orderID string) string {
-	return fmt.Sprintf("%s/getTrackInfo?access_token=%s&lang=%s&order_id=%s", c.BaseURL, token, en, orderID)
+	return c.endpointURL(EndpointGetTrackInfo, c.langQuery(token).
+		set("order_id", orderID))
 }
 
 func (c client) getOrderHistoryURL(token, saleRecordID, orderID string) string {
-	return fmt.Sprintf("%s/getOrderHistory?access_token=%s&lang=%s&sale_record_id=%s&order_id=%s", c.BaseURL, token, en, saleRecordID, orderID)
+	return c.endpointURL(EndpointGetOrderHistory, c.langQuery(token).
+		set("sale_record_id", saleRecordID).
+		set("order_id", orderID))
 }
 
 func (c client) getCountriesURL(token string) string {
-	return fmt.Sprintf("%s/common/getCountries?access_token=%s&lang=%s&", c.BaseURL, token, en)
+	return c.endpointURL(EndpointGetCountries, c.langQuery(token))
 }
 
 func (c client) getStockURL(token, productID string) string {
-	return fmt.Sprintf("%s/product/getStocks?access_token=%s&lang=%s&product_id=%s", c.BaseURL, token, en, productID)
+	return c.endpointURL(EndpointGetStocks, c.langQuery(token).
+		set("product_id", productID))
 }
 
 func (c client) getProductUpdateListURL(token string, minutes, page int) string {
-	return fmt.Sprintf("%s/product/getProductUpdateList?access_token=%s&lang=%s&minutes=%s&page=%s", c.BaseURL, token, en, minutes, page)
+	return c.endpointURL(EndpointGetProductUpdateList, c.langQuery(token).
+		setInt("minutes", minutes).
+		setInt("page", page))
 }
 
 func (c client) getLimitPriceBrandURL(token string, page int) string {
-	return fmt.Sprintf("%s/product/getLimitPriceBrand?access_token=%s&page=%s", c.BaseURL, token, page)
+	return c.endpointURL(EndpointGetLimitPriceBrand, c.tokenQuery(token).
+		setInt("page", page))
 }
 
 func (c client) getBrandLimitPriceListURL(token, brandID string, page int) string {
-	return fmt.Sprintf("%s/product/getBrandLimitPriceList?access_token=%s&page=%s&brand_id=", c.BaseURL, token, page, brandID)
+	return c.endpointURL(EndpointGetBrandLimitPriceList, c.tokenQuery(token).
+		setInt("page", page).
+		set("brand_id", brandID))
 }
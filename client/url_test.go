@@ -0,0 +1,144 @@
+package client
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointURLs(t *testing.T) {
+	c := NewClient("app id", "s&cret", WithCurrency("USD")).(client)
+	tok := "t&k n"
+	page := 3
+	eur := "EUR"
+	// 12:00 UTC is 20:00 in Banggood's timezone.
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	var zero time.Time
+
+	tests := []struct {
+		name     string
+		url      string
+		endpoint string
+		want     url.Values
+	}{
+		{"Translate", c.translateURL(tok, "1 2", "3&4", "CN", ""), EndpointTranslate, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1 2"}, "poa_id": {"3&4"}, "warehouse": {"CN"}, "currency": {"USD"},
+		}},
+		{"GetProductPrice", c.getProductPriceURL(tok, "1", "", "CN", "EUR"), EndpointGetProductPrice, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1"}, "poa_id": {""}, "warehouse": {"CN"}, "currency": {"EUR"},
+		}},
+		{"GetAccessToken", c.getAccessTokenURL(), EndpointGetAccessToken, url.Values{
+			"app_id": {"app id"}, "app_secret": {"s&cret"},
+		}},
+		{"GetCategoryList", c.getCategoryListURL(tok, &page), EndpointGetCategoryList, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "page": {"3"},
+		}},
+		{"GetCategoryList without page", c.getCategoryListURL(tok, nil), EndpointGetCategoryList, url.Values{
+			"access_token": {tok}, "lang": {"en"},
+		}},
+		{"GetProductList", c.getProductListURL(tok, "25", &start, nil, &zero, &start, &page), EndpointGetProductList, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "cat_id": {"25"}, "add_date_start": {"2021-03-01 20:00:00"}, "modify_date_end": {"2021-03-01 20:00:00"}, "page": {"3"},
+		}},
+		{"GetProductInfo", c.getProductInfoURL(tok, "1", nil), EndpointGetProductInfo, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1"}, "currency": {"USD"},
+		}},
+		{"GetProductInfo with currency", c.getProductInfoURL(tok, "1", &eur), EndpointGetProductInfo, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1"}, "currency": {"EUR"},
+		}},
+		{"GetShipments", c.getShipmentsURL(tok, "1", "CN", "United States", "3,4", "", 2), EndpointGetShipments, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1"}, "warehouse": {"CN"}, "country": {"United States"}, "poa_id": {"3,4"}, "quantity": {"2"}, "currency": {"USD"},
+		}},
+		{"ImportOrder", c.importOrderURL(), EndpointImportOrder, url.Values{}},
+		{"GetOrderInfo", c.getOrderInfoURL(tok, "SR 1&2"), EndpointGetOrderInfo, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "sale_record_id": {"SR 1&2"},
+		}},
+		{"GetTrackInfo", c.getTrackInfoURL(tok, "70000001"), EndpointGetTrackInfo, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "order_id": {"70000001"},
+		}},
+		{"GetOrderHistory", c.getOrderHistoryURL(tok, "SR-1", "70000001"), EndpointGetOrderHistory, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "sale_record_id": {"SR-1"}, "order_id": {"70000001"},
+		}},
+		{"GetCountries", c.getCountriesURL(tok), EndpointGetCountries, url.Values{
+			"access_token": {tok}, "lang": {"en"},
+		}},
+		{"GetStock", c.getStockURL(tok, "1"), EndpointGetStocks, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "product_id": {"1"},
+		}},
+		{"GetProductUpdateList", c.getProductUpdateListURL(tok, 60, 2), EndpointGetProductUpdateList, url.Values{
+			"access_token": {tok}, "lang": {"en"}, "minutes": {"60"}, "page": {"2"},
+		}},
+		{"GetLimitPriceBrand", c.getLimitPriceBrandURL(tok, 1), EndpointGetLimitPriceBrand, url.Values{
+			"access_token": {tok}, "page": {"1"},
+		}},
+		{"GetBrandLimitPriceList", c.getBrandLimitPriceListURL(tok, "7 & 8", 4), EndpointGetBrandLimitPriceList, url.Values{
+			"access_token": {tok}, "page": {"4"}, "brand_id": {"7 & 8"},
+		}},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := u.Scheme + "://" + u.Host + u.Path; got != defaultURL+"/"+tt.endpoint {
+			t.Errorf("%s: URL = %s, want %s/%s", tt.name, got, defaultURL, tt.endpoint)
+		}
+		if got := u.Query(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: query = %v, want %v", tt.name, got, tt.want)
+		}
+		if strings.ContainsAny(u.RawQuery, " ") {
+			t.Errorf("%s: query %q has unescaped spaces", tt.name, u.RawQuery)
+		}
+	}
+}
+
+func TestEndpointURLsSandbox(t *testing.T) {
+	c := NewClient("id", "secret", WithSandbox()).(client)
+	page := 1
+	for _, raw := range []string{
+		c.translateURL("tok", "1", "", "CN", ""),
+		c.getProductPriceURL("tok", "1", "", "CN", ""),
+		c.getAccessTokenURL(),
+		c.getCategoryListURL("tok", &page),
+		c.getProductListURL("tok", "1", nil, nil, nil, nil, nil),
+		c.getProductInfoURL("tok", "1", nil),
+		c.getShipmentsURL("tok", "1", "CN", "Germany", "", "", 1),
+		c.importOrderURL(),
+		c.getOrderInfoURL("tok", "SR-1"),
+		c.getTrackInfoURL("tok", "1"),
+		c.getOrderHistoryURL("tok", "SR-1", "1"),
+		c.getCountriesURL("tok"),
+		c.getStockURL("tok", "1"),
+		c.getProductUpdateListURL("tok", 60, 1),
+		c.getLimitPriceBrandURL("tok", 1),
+		c.getBrandLimitPriceListURL("tok", "1", 1),
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Scheme+"://"+u.Host != sandboxURL || u.Query().Get("apiTest") != "1" {
+			t.Errorf("%s is not a sandbox URL", raw)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	c := NewClient("app-id", "app-secret").(client)
+	u, err := url.Parse(c.getAccessTokenURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := redactURL(u); strings.Contains(got, "app-id") || strings.Contains(got, "app-secret") {
+		t.Errorf("redactURL = %s", got)
+	}
+	u, err = url.Parse(c.getStockURL("secret-token", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := redactURL(u); strings.Contains(got, "secret-token") || !strings.Contains(got, "product_id=1") {
+		t.Errorf("redactURL = %s", got)
+	}
+}
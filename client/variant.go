@@ -0,0 +1,184 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VariantWarehouse is the price and stock of a variant in one warehouse.
+type VariantWarehouse struct {
+	Warehouse string `json:"warehouse"`
+	Price     Money  `json:"price"`
+	Stock     int    `json:"stock"`
+}
+
+// Variant is one sellable combination of a product's POA options.
+type Variant struct {
+	// SKU is ProductID followed by the variant's POA IDs in ascending
+	// order, so it does not change when option groups are reordered.
+	SKU        string             `json:"sku"`
+	ProductID  string             `json:"product_id"`
+	Name       string             `json:"name"`
+	Options    []OptionValue      `json:"options"`
+	Warehouses []VariantWarehouse `json:"warehouses"`
+}
+
+// PoaID returns the comma-separated POA IDs of v, as taken by the poa_id
+// parameters.
+func (v Variant) PoaID() string {
+	ids := make([]string, len(v.Options))
+	for i, o := range v.Options {
+		ids[i] = o.PoaID
+	}
+	return strings.Join(ids, ",")
+}
+
+func (v Variant) Warehouse(warehouse string) (VariantWarehouse, bool) {
+	for _, w := range v.Warehouses {
+		if w.Warehouse == warehouse {
+			return w, true
+		}
+	}
+	return VariantWarehouse{}, false
+}
+
+// Stock returns the stock of v across all warehouses.
+func (v Variant) Stock() int {
+	total := 0
+	for _, w := range v.Warehouses {
+		total += w.Stock
+	}
+	return total
+}
+
+// VariantMatrix is every variant of a product.
+type VariantMatrix struct {
+	ProductID string    `json:"product_id"`
+	Variants  []Variant `json:"variants"`
+}
+
+// NewVariantMatrix expands the option groups of info into variants, one per
+// combination of values. A variant's price in a warehouse is the warehouse
+// price plus its options' prices. Stock is reported per POA, so the stock of
+// a combination is the lowest stock of its POAs. It returns an error
+// wrapping ErrCurrencyMismatch if the prices of info are in different
+// currencies.
+func NewVariantMatrix(productID string, info GetProductInfoResponse, stock GetStockResponse) (VariantMatrix, error) {
+	var groups [][]OptionValue
+	for _, option := range info.PoaList {
+		if len(option.OptionValues) > 0 {
+			groups = append(groups, option.OptionValues)
+		}
+	}
+
+	combinations := [][]OptionValue{nil}
+	for _, values := range groups {
+		var next [][]OptionValue
+		for _, combination := range combinations {
+			for _, value := range values {
+				next = append(next, append(append([]OptionValue(nil), combination...), value))
+			}
+		}
+		combinations = next
+	}
+
+	stocks := map[string]map[string]int{}
+	for _, w := range stock.Stocks {
+		if stocks[w.Warehouse] == nil {
+			stocks[w.Warehouse] = map[string]int{}
+		}
+		for _, s := range w.StocksList {
+			n, _ := strconv.Atoi(strings.TrimSpace(s.Stock))
+			stocks[w.Warehouse][strconv.Itoa(s.PoaID)] += n
+		}
+	}
+
+	m := VariantMatrix{ProductID: productID}
+	for _, options := range combinations {
+		v := Variant{
+			SKU:       variantSKU(productID, options),
+			ProductID: productID,
+			Options:   options,
+		}
+		names := make([]string, len(options))
+		for i, o := range options {
+			names[i] = o.PoaName
+		}
+		v.Name = strings.Join(names, " / ")
+		for _, w := range info.WarehouseList {
+			vw := VariantWarehouse{
+				Warehouse: w.Warehouse,
+				Price:     w.WarehousePrice,
+				Stock:     variantStock(stocks[w.Warehouse], options),
+			}
+			for _, o := range options {
+				price, err := vw.Price.Add(o.PoaPrice)
+				if err != nil {
+					return VariantMatrix{}, fmt.Errorf("product %s option %s: %w", productID, o.PoaID, err)
+				}
+				vw.Price = price
+			}
+			v.Warehouses = append(v.Warehouses, vw)
+		}
+		m.Variants = append(m.Variants, v)
+	}
+	return m, nil
+}
+
+func variantSKU(productID string, options []OptionValue) string {
+	ids := make([]string, len(options))
+	for i, o := range options {
+		ids[i] = o.PoaID
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+	return strings.Join(append([]string{productID}, ids...), "-")
+}
+
+// variantStock returns the lowest stock of options in a warehouse, or the
+// warehouse total for products without options.
+func variantStock(stocks map[string]int, options []OptionValue) int {
+	if len(options) == 0 {
+		total := 0
+		for _, n := range stocks {
+			total += n
+		}
+		return total
+	}
+	lowest := -1
+	for _, o := range options {
+		n := stocks[o.PoaID]
+		if lowest < 0 || n < lowest {
+			lowest = n
+		}
+	}
+	return lowest
+}
+
+func (m VariantMatrix) Lookup(sku string) (Variant, bool) {
+	for _, v := range m.Variants {
+		if v.SKU == sku {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// InStock returns the variants with stock in at least one warehouse.
+func (m VariantMatrix) InStock() []Variant {
+	var variants []Variant
+	for _, v := range m.Variants {
+		if v.Stock() > 0 {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
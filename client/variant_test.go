@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func usd(cents int64) client.Money {
+	return client.NewMoney(cents, 2, "USD")
+}
+
+// variantProduct has two colours and two sizes, stocked in CN and US.
+func variantProduct() banggoodtest.Product {
+	stock := func(warehouse string, counts ...string) client.WarehouseStock {
+		w := client.WarehouseStock{Warehouse: warehouse}
+		for i, poaID := range []int{100, 101, 200, 201} {
+			w.StocksList = append(w.StocksList, client.PoaStock{PoaID: poaID, Stock: counts[i]})
+		}
+		return w
+	}
+	return banggoodtest.Product{
+		Product: client.Product{ProductID: "P1", ProductName: "Shirt"},
+		Info: client.GetProductInfoResponse{
+			PoaList: []client.ProductOption{
+				{OptionID: 1, OptionName: "Color", OptionValues: []client.OptionValue{
+					{PoaID: "101", PoaName: "Red", PoaPrice: usd(100)},
+					{PoaID: "100", PoaName: "Black", PoaPrice: usd(0)},
+				}},
+				{OptionID: 2, OptionName: "Size", OptionValues: []client.OptionValue{
+					{PoaID: "200", PoaName: "S", PoaPrice: usd(0)},
+					{PoaID: "201", PoaName: "L", PoaPrice: usd(50)},
+				}},
+			},
+			WarehouseList: []client.ProductWarehouse{
+				{Warehouse: "CN", WarehousePrice: usd(1000)},
+				{Warehouse: "US", WarehousePrice: usd(1200)},
+			},
+		},
+		Stocks: []client.WarehouseStock{
+			stock("CN", "5", "0", "3", "7"),
+			stock("US", "2", "4", "0", " 9 "),
+		},
+	}
+}
+
+func TestGetVariantMatrix(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{variantProduct()}})
+	m, err := srv.ManagedClient().GetVariantMatrix(context.Background(), "P1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		sku, name, poaID string
+		cn, us           int
+		cnPrice, usPrice client.Money
+	}{
+		{"P1-101-200", "Red / S", "101,200", 0, 0, usd(1100), usd(1300)},
+		{"P1-101-201", "Red / L", "101,201", 0, 4, usd(1150), usd(1350)},
+		{"P1-100-200", "Black / S", "100,200", 3, 0, usd(1000), usd(1200)},
+		{"P1-100-201", "Black / L", "100,201", 5, 2, usd(1050), usd(1250)},
+	}
+	if len(m.Variants) != len(want) {
+		t.Fatalf("matrix has %d variants, want %d", len(m.Variants), len(want))
+	}
+	for i, w := range want {
+		v := m.Variants[i]
+		cn, _ := v.Warehouse("CN")
+		us, _ := v.Warehouse("US")
+		if v.SKU != w.sku || v.Name != w.name || v.PoaID() != w.poaID || cn.Stock != w.cn || us.Stock != w.us ||
+			cn.Price.MustCmp(w.cnPrice) != 0 || us.Price.MustCmp(w.usPrice) != 0 {
+			t.Errorf("variant %d = %+v", i, v)
+		}
+	}
+	if v, ok := m.Lookup("P1-100-201"); !ok || v.Stock() != 7 {
+		t.Errorf("Lookup = %+v, %v", v, ok)
+	}
+	if _, ok := m.Lookup("P1-100"); ok {
+		t.Error("Lookup found a partial SKU")
+	}
+	if in := m.InStock(); len(in) != 3 || in[0].Name != "Red / L" {
+		t.Errorf("InStock = %+v", in)
+	}
+	if _, ok := m.Variants[0].Warehouse("UK"); ok {
+		t.Error("Warehouse found UK")
+	}
+
+	p := variantProduct()
+	p.Info.PoaList[0], p.Info.PoaList[1] = p.Info.PoaList[1], p.Info.PoaList[0]
+	swapped, err := client.NewVariantMatrix("P1", p.Info, client.GetStockResponse{Stocks: p.Stocks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := swapped.Lookup("P1-101-201"); !ok || v.Name != "L / Red" {
+		t.Errorf("SKU changed when option groups were reordered: %+v", swapped.Variants)
+	}
+}
+
+func TestGetVariantMatrixWithoutOptions(t *testing.T) {
+	p := variantProduct()
+	p.Info.PoaList = nil
+	m, err := client.NewVariantMatrix("P1", p.Info, client.GetStockResponse{Stocks: p.Stocks})
+	if err != nil || len(m.Variants) != 1 || m.Variants[0].SKU != "P1" || m.Variants[0].Stock() != 15+15 {
+		t.Errorf("matrix without options = %+v, %v", m.Variants, err)
+	}
+}
+
+func TestGetVariantMatrixErrors(t *testing.T) {
+	p := variantProduct()
+	p.Info.PoaList[1].OptionValues[1].PoaPrice = p.Info.PoaList[1].OptionValues[1].PoaPrice.In("EUR")
+	if _, err := client.NewVariantMatrix("P1", p.Info, client.GetStockResponse{Stocks: p.Stocks}); !errors.Is(err, client.ErrCurrencyMismatch) {
+		t.Errorf("NewVariantMatrix with an EUR option = %v, want ErrCurrencyMismatch", err)
+	}
+
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{variantProduct()}})
+	c := srv.ManagedClient()
+
+	srv.InjectFault(client.EndpointGetStocks, banggoodtest.Fault{Code: client.CodeInvalidParameter})
+	if _, err := c.GetVariantMatrix(context.Background(), "P1", nil); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetVariantMatrix with failing stock = %v", err)
+	}
+	if _, err := c.GetVariantMatrix(context.Background(), "missing", nil); !errors.Is(err, client.ErrBadParameter) {
+		t.Errorf("GetVariantMatrix of a missing product = %v", err)
+	}
+}
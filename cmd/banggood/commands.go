@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+type env struct {
+	raw     client.BanggoodClient
+	managed client.ManagedClient
+}
+
+// command registers its flags on a FlagSet and returns the function that
+// runs it once the flags are parsed.
+type command struct {
+	summary string
+	setup   func(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error)
+}
+
+var commands = map[string]command{
+	"token":         {"fetch an access token", tokenCommand},
+	"categories":    {"list categories", categoriesCommand},
+	"products":      {"list products in a category", productsCommand},
+	"product-info":  {"show product options and warehouses", productInfoCommand},
+	"shipments":     {"quote shipping for a product", shipmentsCommand},
+	"stock":         {"show per-warehouse stock", stockCommand},
+	"order-info":    {"show orders for a sale record", orderInfoCommand},
+	"track":         {"show tracking events for an order", trackCommand},
+	"order-history": {"show status history for an order", orderHistoryCommand},
+	"countries":     {"list destination countries", countriesCommand},
+	"updates":       {"list products changed in the last N minutes", updatesCommand},
+	"brands":        {"list limit-price brands or a brand's limit prices", brandsCommand},
+}
+
+func required(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("-%s is required", name)
+	}
+	return nil
+}
+
+func tokenCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	return func(ctx context.Context, e *env) (result, error) {
+		res, err := e.raw.GetAccessToken(ctx)
+		if err != nil {
+			return result{}, err
+		}
+		return result{
+			data:   res,
+			header: []string{"access_token", "expires_in"},
+			rows:   [][]string{{res.AccessToken, strconv.Itoa(res.ExpiresIn)}},
+		}, nil
+	}
+}
+
+func categoriesCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	page := fs.Int("page", 0, "fetch a single page instead of all")
+	return func(ctx context.Context, e *env) (result, error) {
+		var categories []client.Category
+		var err error
+		if *page > 0 {
+			var res client.GetCategoryListResponse
+			res, err = e.managed.GetCategoryList(ctx, page)
+			categories = res.CategoryList
+		} else {
+			categories, err = e.managed.GetAllCategories(ctx)
+		}
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: categories, header: []string{"cat_id", "cat_name", "parent_id"}}
+		for _, c := range categories {
+			r.rows = append(r.rows, []string{c.CategoryID, c.CategoryName, c.ParentID})
+		}
+		return r, nil
+	}
+}
+
+func productsCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	category := fs.String("category", "", "category ID")
+	page := fs.Int("page", 0, "fetch a single page instead of all")
+	since := fs.Duration("modified-within", 0, "only products modified within this duration")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("category", *category); err != nil {
+			return result{}, err
+		}
+		var modifiedFrom *time.Time
+		if *since > 0 {
+			t := time.Now().Add(-*since)
+			modifiedFrom = &t
+		}
+		var products []client.Product
+		var err error
+		if *page > 0 {
+			var res client.GetProductListResponse
+			res, err = e.managed.GetProductList(ctx, *category, nil, nil, modifiedFrom, nil, page)
+			products = res.ProductList
+		} else {
+			products, err = e.managed.GetAllProducts(ctx, *category, nil, nil, modifiedFrom, nil)
+		}
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: products, header: []string{"product_id", "cat_id", "product_name", "add_date", "modify_date"}}
+		for _, p := range products {
+			r.rows = append(r.rows, []string{p.ProductID, strconv.Itoa(p.CategoryID), p.ProductName, p.AddDate.String(), p.ModifyDate.String()})
+		}
+		return r, nil
+	}
+}
+
+func productInfoCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	id := fs.String("id", "", "product ID")
+	currency := fs.String("currency", "", "price currency")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("id", *id); err != nil {
+			return result{}, err
+		}
+		var cur *string
+		if *currency != "" {
+			cur = currency
+		}
+		res, err := e.managed.GetProductInfo(ctx, *id, cur)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res, header: []string{"kind", "group", "id", "name", "price"}}
+		for _, w := range res.WarehouseList {
+			r.rows = append(r.rows, []string{"warehouse", "", w.Warehouse, w.Warehouse, w.WarehousePrice.Amount()})
+		}
+		for _, option := range res.PoaList {
+			for _, value := range option.OptionValues {
+				r.rows = append(r.rows, []string{"option", option.OptionName, value.PoaID, value.PoaName, value.PoaPrice.Amount()})
+			}
+		}
+		return r, nil
+	}
+}
+
+func shipmentsCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	id := fs.String("id", "", "product ID")
+	warehouse := fs.String("warehouse", "CN", "warehouse")
+	country := fs.String("country", "", "destination country")
+	poa := fs.String("poa", "", "POA ID")
+	quantity := fs.Int("quantity", 1, "quantity")
+	currency := fs.String("currency", "", "fee currency")
+	tracked := fs.Bool("tracked", false, "only methods with tracking")
+	within := fs.Int("within", 0, "only methods delivering within this many days")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("id", *id); err != nil {
+			return result{}, err
+		}
+		if err := required("country", *country); err != nil {
+			return result{}, err
+		}
+		res, err := e.managed.GetShipments(ctx, *id, *warehouse, *country, *poa, *currency, *quantity)
+		if err != nil {
+			return result{}, err
+		}
+		methods := res.ShipMethods
+		if *tracked {
+			methods = methods.Tracked()
+		}
+		if *within > 0 {
+			methods = methods.Within(*within)
+		}
+		r := result{data: methods, header: []string{"shipmethod_code", "shipmethod_name", "shipday", "shipfee", "currency", "tracked"}}
+		for _, m := range methods {
+			r.rows = append(r.rows, []string{m.ShipMethodCode, m.ShipMethodName, m.Shipday, m.Shipfee.Amount(), res.Currency, strconv.FormatBool(m.Tracked())})
+		}
+		return r, nil
+	}
+}
+
+func stockCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	id := fs.String("id", "", "product ID")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("id", *id); err != nil {
+			return result{}, err
+		}
+		res, err := e.managed.GetStock(ctx, *id)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res, header: []string{"warehouse", "poa_id", "poa", "stock", "message"}}
+		for _, w := range res.Stocks {
+			for _, s := range w.StocksList {
+				r.rows = append(r.rows, []string{w.Warehouse, strconv.Itoa(s.PoaID), s.Poa, s.Stock, s.StocksMessage})
+			}
+		}
+		return r, nil
+	}
+}
+
+func orderInfoCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	saleRecord := fs.String("sale-record", "", "sale record ID")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("sale-record", *saleRecord); err != nil {
+			return result{}, err
+		}
+		res, err := e.managed.GetOrderInfo(ctx, *saleRecord)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res, header: []string{"sale_record_id", "order_id", "status", "total_amount", "currency", "shipment_method"}}
+		for _, sr := range res.SaleRecordIDList {
+			for _, o := range sr.OrderList {
+				r.rows = append(r.rows, []string{sr.SaleRecordID, o.OrderID, o.Status, o.TotalAmount.Amount(), o.Currency, o.ShipmethodCode})
+			}
+		}
+		return r, nil
+	}
+}
+
+func trackCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	order := fs.String("order", "", "order ID")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("order", *order); err != nil {
+			return result{}, err
+		}
+		res, err := e.managed.GetTrackInfo(ctx, *order)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res, header: []string{"time", "event"}}
+		for _, t := range res.TrackInfo {
+			r.rows = append(r.rows, []string{t.Time, t.Event})
+		}
+		return r, nil
+	}
+}
+
+func orderHistoryCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	saleRecord := fs.String("sale-record", "", "sale record ID")
+	order := fs.String("order", "", "order ID")
+	return func(ctx context.Context, e *env) (result, error) {
+		if err := required("order", *order); err != nil {
+			return result{}, err
+		}
+		res, err := e.managed.GetOrderHistory(ctx, *saleRecord, *order)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res, header: []string{"date_add", "status", "track_number"}}
+		for _, h := range res.OrderHistory {
+			r.rows = append(r.rows, []string{h.DateAdd, h.Status, res.TrackNumber})
+		}
+		return r, nil
+	}
+}
+
+func countriesCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	return func(ctx context.Context, e *env) (result, error) {
+		res, err := e.managed.GetCountries(ctx)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: res.Countries, header: []string{"country_id", "country_name"}}
+		for _, c := range res.Countries {
+			r.rows = append(r.rows, []string{strconv.Itoa(c.CountryID), c.CountryName})
+		}
+		return r, nil
+	}
+}
+
+func updatesCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	minutes := fs.Int("minutes", 60, "look-back window in minutes")
+	return func(ctx context.Context, e *env) (result, error) {
+		updates, err := e.managed.GetAllProductUpdates(ctx, *minutes)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: updates, header: []string{"product_id", "state", "modify_date"}}
+		for _, u := range updates {
+			r.rows = append(r.rows, []string{u.ProductID, strconv.Itoa(u.State), u.ModifyDate.String()})
+		}
+		return r, nil
+	}
+}
+
+func brandsCommand(fs *flag.FlagSet) func(ctx context.Context, e *env) (result, error) {
+	brand := fs.String("brand", "", "brand ID; lists the brand's limit prices")
+	return func(ctx context.Context, e *env) (result, error) {
+		if *brand == "" {
+			brands, err := e.managed.GetAllLimitPriceBrands(ctx)
+			if err != nil {
+				return result{}, err
+			}
+			r := result{data: brands, header: []string{"brand_id", "name"}}
+			for _, b := range brands {
+				r.rows = append(r.rows, []string{b.BrandID, b.Name})
+			}
+			return r, nil
+		}
+
+		prices, err := e.managed.GetAllBrandLimitPrices(ctx, *brand)
+		if err != nil {
+			return result{}, err
+		}
+		r := result{data: prices, header: []string{"product_id", "sku", "poa", "limit_price"}}
+		for _, p := range prices {
+			r.rows = append(r.rows, []string{p.ProductID, p.Sku, p.Poa, p.LimitPrice.Amount()})
+		}
+		return r, nil
+	}
+}
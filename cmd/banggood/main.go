@@ -0,0 +1,186 @@
+// Command banggood queries the Banggood API from the command line.
+//
+// Credentials are read from flags, then the BANGGOOD_APP_ID,
+// BANGGOOD_APP_SECRET, BANGGOOD_SANDBOX and BANGGOOD_BASE_URL environment
+// variables, then a JSON config file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+type config struct {
+	AppID     string `json:"app_id"`
+	AppSecret string `json:"app_secret"`
+	BaseURL   string `json:"base_url"`
+	Sandbox   bool   `json:"sandbox"`
+	Language  string `json:"lang"`
+	Currency  string `json:"currency"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "banggood", "config.json")
+}
+
+// loadConfig merges the config file, the environment and flags, in
+// increasing order of precedence.
+func loadConfig(path string, flags config) (config, error) {
+	var cfg config
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(b, &cfg); err != nil {
+				return config{}, fmt.Errorf("reading %s: %w", path, err)
+			}
+		case !os.IsNotExist(err):
+			return config{}, err
+		}
+	}
+
+	if v := os.Getenv("BANGGOOD_APP_ID"); v != "" {
+		cfg.AppID = v
+	}
+	if v := os.Getenv("BANGGOOD_APP_SECRET"); v != "" {
+		cfg.AppSecret = v
+	}
+	if v := os.Getenv("BANGGOOD_BASE_URL"); v != "" {
+		cfg.BaseURL = v
+	}
+	if v := os.Getenv("BANGGOOD_SANDBOX"); v != "" {
+		sandbox, err := strconv.ParseBool(v)
+		if err != nil {
+			return config{}, fmt.Errorf("BANGGOOD_SANDBOX: %w", err)
+		}
+		cfg.Sandbox = sandbox
+	}
+
+	if flags.AppID != "" {
+		cfg.AppID = flags.AppID
+	}
+	if flags.AppSecret != "" {
+		cfg.AppSecret = flags.AppSecret
+	}
+	if flags.BaseURL != "" {
+		cfg.BaseURL = flags.BaseURL
+	}
+	if flags.Sandbox {
+		cfg.Sandbox = true
+	}
+	if flags.Language != "" {
+		cfg.Language = flags.Language
+	}
+	if flags.Currency != "" {
+		cfg.Currency = flags.Currency
+	}
+
+	if cfg.AppID == "" || cfg.AppSecret == "" {
+		return config{}, errors.New("missing credentials: set -app-id/-app-secret, BANGGOOD_APP_ID/BANGGOOD_APP_SECRET or the config file")
+	}
+	return cfg, nil
+}
+
+func (cfg config) client() client.BanggoodClient {
+	var opts []client.Option
+	if cfg.BaseURL != "" {
+		opts = append(opts, client.WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.Sandbox {
+		opts = append(opts, client.WithSandbox())
+	}
+	if cfg.Language != "" {
+		opts = append(opts, client.WithLanguage(cfg.Language))
+	}
+	if cfg.Currency != "" {
+		opts = append(opts, client.WithCurrency(cfg.Currency))
+	}
+	return client.NewClient(cfg.AppID, cfg.AppSecret, opts...)
+}
+
+func usage(global *flag.FlagSet) func() {
+	return func() {
+		out := global.Output()
+		fmt.Fprintf(out, "Usage: banggood [flags] <command> [command flags]\n\nCommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "  %-14s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(out, "\nFlags:\n")
+		global.PrintDefaults()
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "banggood:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("banggood", flag.ExitOnError)
+	var flags config
+	configPath := global.String("config", defaultConfigPath(), "path to a JSON config file")
+	format := global.String("format", "table", "output format: table, json or csv")
+	global.StringVar(&flags.AppID, "app-id", "", "Banggood app ID")
+	global.StringVar(&flags.AppSecret, "app-secret", "", "Banggood app secret")
+	global.StringVar(&flags.BaseURL, "base-url", "", "API base URL")
+	global.BoolVar(&flags.Sandbox, "sandbox", false, "use the Banggood beta environment")
+	global.StringVar(&flags.Language, "lang", "", "response language")
+	global.StringVar(&flags.Currency, "currency", "", "default currency")
+	global.Usage = usage(global)
+	_ = global.Parse(args)
+
+	if global.NArg() == 0 {
+		global.Usage()
+		return errors.New("no command given")
+	}
+	name := global.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		global.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	out, err := newPrinter(*format, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath, flags)
+	if err != nil {
+		return err
+	}
+	c := cfg.client()
+	env := &env{
+		raw:     c,
+		managed: client.NewManagedClient(c, client.NewTokenSource(c)),
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	exec := cmd.setup(fs)
+	_ = fs.Parse(global.Args()[1:])
+	res, err := exec(context.Background(), env)
+	if err != nil {
+		return err
+	}
+	return out(res)
+}
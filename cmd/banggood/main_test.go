@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// setenv sets key for the duration of the test; an empty value unsets it.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, key := range []string{"BANGGOOD_APP_ID", "BANGGOOD_APP_SECRET", "BANGGOOD_BASE_URL", "BANGGOOD_SANDBOX"} {
+		setenv(t, key, "")
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"app_id":"file-id","app_secret":"file-secret","base_url":"https://file.example/","lang":"de","currency":"EUR"}`
+	if err := ioutil.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path, config{})
+	want := config{AppID: "file-id", AppSecret: "file-secret", BaseURL: "https://file.example/", Language: "de", Currency: "EUR"}
+	if err != nil || cfg != want {
+		t.Errorf("config from file = %+v, %v", cfg, err)
+	}
+
+	setenv(t, "BANGGOOD_APP_SECRET", "env-secret")
+	setenv(t, "BANGGOOD_SANDBOX", "true")
+	cfg, err = loadConfig(path, config{AppID: "flag-id", Currency: "GBP"})
+	want = config{AppID: "flag-id", AppSecret: "env-secret", BaseURL: "https://file.example/", Sandbox: true, Language: "de", Currency: "GBP"}
+	if err != nil || cfg != want {
+		t.Errorf("merged config = %+v, %v; want %+v", cfg, err, want)
+	}
+
+	setenv(t, "BANGGOOD_SANDBOX", "maybe")
+	if _, err := loadConfig(path, config{}); err == nil {
+		t.Error("loadConfig accepted BANGGOOD_SANDBOX=maybe")
+	}
+	setenv(t, "BANGGOOD_SANDBOX", "")
+	setenv(t, "BANGGOOD_APP_SECRET", "")
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"), config{AppID: "id"}); err == nil || !strings.Contains(err.Error(), "missing credentials") {
+		t.Errorf("loadConfig without a secret = %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path, config{AppID: "id", AppSecret: "secret"}); err == nil {
+		t.Error("loadConfig accepted a malformed file")
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	r := result{
+		data:   map[string]string{"id": "1"},
+		header: []string{"id", "name"},
+		rows:   [][]string{{"1", "Phones, Cases"}, {"22", "Garden"}},
+	}
+	tests := map[string]string{
+		"table": "id  name\n1   Phones, Cases\n22  Garden\n",
+		"csv":   "id,name\n1,\"Phones, Cases\"\n22,Garden\n",
+		"json":  "{\n  \"id\": \"1\"\n}\n",
+	}
+	for format, want := range tests {
+		var buf bytes.Buffer
+		out, err := newPrinter(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := out(r); err != nil || buf.String() != want {
+			t.Errorf("%s output = %q, %v; want %q", format, buf.String(), err, want)
+		}
+	}
+	if _, err := newPrinter("xml", ioutil.Discard); err == nil {
+		t.Error("newPrinter accepted xml")
+	}
+}
+
+// execute runs a command against srv the way run does, without touching
+// the process's flags or output.
+func execute(srv *banggoodtest.Server, args ...string) (result, error) {
+	c := srv.BanggoodClient()
+	e := &env{raw: c, managed: client.NewManagedClient(c, client.NewTokenSource(c))}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	exec := commands[args[0]].setup(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return result{}, err
+	}
+	return exec(context.Background(), e)
+}
+
+func TestCommands(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(5, 2, 2)
+	srv.Seed(catalog)
+	p := catalog.Products[0]
+
+	tests := []struct {
+		args   []string
+		header string
+		rows   int
+	}{
+		{[]string{"token"}, "access_token", 1},
+		{[]string{"categories"}, "cat_id", len(catalog.Categories)},
+		{[]string{"categories", "-page", "1"}, "cat_id", len(catalog.Categories)},
+		{[]string{"products", "-category", catalog.Categories[0].CategoryID}, "product_id", 2},
+		{[]string{"product-info", "-id", p.ProductID}, "kind", infoRows(p)},
+		{[]string{"shipments", "-id", p.ProductID, "-country", "Germany"}, "shipmethod_code", len(p.Shipments)},
+		{[]string{"shipments", "-id", p.ProductID, "-country", "Germany", "-within", "1"}, "shipmethod_code", 0},
+		{[]string{"stock", "-id", p.ProductID}, "warehouse", stockRows(p)},
+		{[]string{"countries"}, "country_id", len(catalog.Countries)},
+		{[]string{"updates"}, "product_id", 0},
+		{[]string{"brands"}, "brand_id", len(catalog.Brands)},
+	}
+	for _, tt := range tests {
+		r, err := execute(srv, tt.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if r.header[0] != tt.header || len(r.rows) != tt.rows {
+			t.Errorf("%v = %v with %d rows, want %s with %d", tt.args, r.header, len(r.rows), tt.header, tt.rows)
+		}
+		for _, row := range r.rows {
+			if len(row) != len(r.header) {
+				t.Errorf("%v row %v does not match header %v", tt.args, row, r.header)
+			}
+		}
+	}
+}
+
+func infoRows(p banggoodtest.Product) int {
+	n := len(p.Info.WarehouseList)
+	for _, option := range p.Info.PoaList {
+		n += len(option.OptionValues)
+	}
+	return n
+}
+
+func stockRows(p banggoodtest.Product) int {
+	n := 0
+	for _, w := range p.Stocks {
+		n += len(w.StocksList)
+	}
+	return n
+}
+
+func TestOrderCommands(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(2, 1, 1)
+	srv.Seed(catalog)
+	p := catalog.Products[0]
+	srv.SetStock(p.ProductID, p.Stocks[0].Warehouse, p.Stocks[0].StocksList[0].PoaID, 5)
+	_, err := srv.BanggoodClient().ImportOrder(context.Background(), client.ImportOrderRequest{
+		AccessToken:           srv.IssueToken(),
+		SaleRecordID:          "SR-1",
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          1,
+		ProductList:           []client.ImportOrderProduct{{ProductID: p.ProductID, Warehouse: p.Stocks[0].Warehouse, Quantity: "1", ShipmethodCode: "airmail"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderID := srv.OrderIDs("SR-1")[0]
+	srv.AddTrackEvent(orderID, "LP123", "Departed")
+
+	r, err := execute(srv, "order-info", "-sale-record", "SR-1")
+	if err != nil || len(r.rows) != 1 || r.rows[0][1] != orderID || r.rows[0][2] != banggoodtest.StatusProcessing {
+		t.Errorf("order-info = %v, %v", r.rows, err)
+	}
+	r, err = execute(srv, "track", "-order", orderID)
+	if err != nil || len(r.rows) != 1 || r.rows[0][1] != "Departed" {
+		t.Errorf("track = %v, %v", r.rows, err)
+	}
+	r, err = execute(srv, "order-history", "-order", orderID)
+	if err != nil || len(r.rows) != 1 || !reflect.DeepEqual(r.rows[0][1:], []string{banggoodtest.StatusProcessing, "LP123"}) {
+		t.Errorf("order-history = %v, %v", r.rows, err)
+	}
+}
+
+func TestCommandsRequireFlags(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	for _, args := range [][]string{
+		{"products"},
+		{"product-info"},
+		{"shipments", "-id", "1"},
+		{"stock"},
+		{"order-info"},
+		{"track"},
+		{"order-history", "-sale-record", "SR-1"},
+	} {
+		if _, err := execute(srv, args...); err == nil || !strings.Contains(err.Error(), "is required") {
+			t.Errorf("%v = %v, want a missing flag error", args, err)
+		}
+	}
+	if n := srv.Requests(client.EndpointGetAccessToken); n != 0 {
+		t.Errorf("commands with missing flags fetched %d tokens", n)
+	}
+}
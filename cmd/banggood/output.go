@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// result is what a command produces: the raw response for JSON output and
+// a flattened table for table and CSV output.
+type result struct {
+	data   interface{}
+	header []string
+	rows   [][]string
+}
+
+type printer func(result) error
+
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "json":
+		return func(r result) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(r.data)
+		}, nil
+	case "csv":
+		return func(r result) error {
+			cw := csv.NewWriter(w)
+			if err := cw.Write(r.header); err != nil {
+				return err
+			}
+			if err := cw.WriteAll(r.rows); err != nil {
+				return err
+			}
+			cw.Flush()
+			return cw.Error()
+		}, nil
+	case "table":
+		return func(r result) error {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, strings.Join(r.header, "\t"))
+			for _, row := range r.rows {
+				fmt.Fprintln(tw, strings.Join(row, "\t"))
+			}
+			return tw.Flush()
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
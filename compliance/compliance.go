@@ -0,0 +1,215 @@
+// Package compliance checks listed prices against brand limit prices
+// (minimum advertised prices).
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+var (
+	ErrBelowLimitPrice = errors.New("compliance: price below brand limit price")
+	ErrUncheckable     = errors.New("compliance: listing not in the limit price currency")
+)
+
+// Limit is the lowest price a brand allows for a product, or for one POA
+// of it when Poa is set.
+type Limit struct {
+	BrandID    string       `json:"brand_id"`
+	BrandName  string       `json:"brand_name"`
+	ProductID  string       `json:"product_id"`
+	Sku        string       `json:"sku"`
+	Poa        string       `json:"poa"`
+	LimitPrice client.Money `json:"limit_price"`
+}
+
+type limitKey struct {
+	productID, poa string
+}
+
+func normalizePoa(poa string) string {
+	return strings.ToLower(strings.TrimSpace(poa))
+}
+
+// Limits indexes limit prices by product and POA.
+type Limits struct {
+	// Currency is the currency limit prices are set in. The API does not
+	// report it, so it must come from the account's settings.
+	Currency string
+
+	limits map[limitKey]Limit
+}
+
+// NewLimits indexes limits, whose prices are in currency. When several
+// limits cover the same product and POA, the highest wins.
+func NewLimits(currency string, limits []Limit) *Limits {
+	l := &Limits{Currency: currency, limits: map[limitKey]Limit{}}
+	for _, limit := range limits {
+		limit.LimitPrice = limit.LimitPrice.In(currency)
+		key := limitKey{limit.ProductID, normalizePoa(limit.Poa)}
+		if prev, ok := l.limits[key]; ok && prev.LimitPrice.MustCmp(limit.LimitPrice) >= 0 {
+			continue
+		}
+		l.limits[key] = limit
+	}
+	return l
+}
+
+// Load fetches every limit-price brand and the limits of each, whose prices
+// are in currency.
+func Load(ctx context.Context, c client.ManagedClient, currency string) (*Limits, error) {
+	brands, err := c.GetAllLimitPriceBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var limits []Limit
+	for _, brand := range brands {
+		prices, err := c.GetAllBrandLimitPrices(ctx, brand.BrandID)
+		if err != nil {
+			return nil, fmt.Errorf("brand %s: %w", brand.BrandID, err)
+		}
+		for _, p := range prices {
+			limits = append(limits, Limit{
+				BrandID:    brand.BrandID,
+				BrandName:  brand.Name,
+				ProductID:  p.ProductID,
+				Sku:        p.Sku,
+				Poa:        p.Poa,
+				LimitPrice: p.LimitPrice,
+			})
+		}
+	}
+	return NewLimits(currency, limits), nil
+}
+
+func (l *Limits) Len() int {
+	return len(l.limits)
+}
+
+// Lookup returns the limit for productID with poa, falling back to a limit
+// covering the whole product.
+func (l *Limits) Lookup(productID, poa string) (Limit, bool) {
+	if limit, ok := l.limits[limitKey{productID, normalizePoa(poa)}]; ok {
+		return limit, true
+	}
+	limit, ok := l.limits[limitKey{productID, ""}]
+	return limit, ok
+}
+
+// Listing is a price we advertise for a product. Only listings in the
+// limits' currency can be checked.
+type Listing struct {
+	ProductID string       `json:"product_id"`
+	Sku       string       `json:"sku"`
+	Poa       string       `json:"poa"`
+	Price     client.Money `json:"price"`
+}
+
+// Violation is a listing priced below its limit. Delta is the amount the
+// price must rise to comply.
+type Violation struct {
+	ProductID  string       `json:"product_id"`
+	Sku        string       `json:"sku"`
+	Poa        string       `json:"poa"`
+	BrandID    string       `json:"brand_id"`
+	BrandName  string       `json:"brand_name"`
+	Price      client.Money `json:"price"`
+	LimitPrice client.Money `json:"limit_price"`
+	Delta      client.Money `json:"delta"`
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("compliance: product %s (%s) priced %s, below %s limit price %s by %s",
+		v.ProductID, v.Poa, v.Price, v.BrandName, v.LimitPrice, v.Delta)
+}
+
+func (v *Violation) Is(target error) bool {
+	return target == ErrBelowLimitPrice
+}
+
+// check returns the violation of listing, or nil if it complies or has no
+// limit. It reports whether listing has a limit, and an error wrapping
+// ErrUncheckable if the listing is in another currency.
+func (l *Limits) check(listing Listing) (*Violation, bool, error) {
+	limit, ok := l.Lookup(listing.ProductID, listing.Poa)
+	if !ok {
+		return nil, false, nil
+	}
+	limitPrice := limit.LimitPrice
+	if !listing.Price.SameCurrency(limitPrice) {
+		return nil, true, fmt.Errorf("%w: product %s priced in %s, limit in %s", ErrUncheckable, listing.ProductID, listing.Price.Currency, limitPrice.Currency)
+	}
+	if listing.Price.MustCmp(limitPrice) >= 0 {
+		return nil, true, nil
+	}
+	sku := listing.Sku
+	if sku == "" {
+		sku = limit.Sku
+	}
+	return &Violation{
+		ProductID:  listing.ProductID,
+		Sku:        sku,
+		Poa:        listing.Poa,
+		BrandID:    limit.BrandID,
+		BrandName:  limit.BrandName,
+		Price:      listing.Price,
+		LimitPrice: limitPrice,
+		Delta:      limitPrice.MustSub(listing.Price),
+	}, true, nil
+}
+
+// Report is the result of checking a set of listings.
+type Report struct {
+	Checked int `json:"checked"`
+	// Limited is the number of listings that have a limit price.
+	Limited    int         `json:"limited"`
+	Violations []Violation `json:"violations"`
+	// Unchecked are limited listings priced in another currency than the
+	// limits.
+	Unchecked []Listing `json:"unchecked"`
+}
+
+// Check compares listings against l. Violations are sorted by product and
+// POA.
+func (l *Limits) Check(listings []Listing) Report {
+	r := Report{Checked: len(listings)}
+	for _, listing := range listings {
+		v, limited, err := l.check(listing)
+		if limited {
+			r.Limited++
+		}
+		if err != nil {
+			r.Unchecked = append(r.Unchecked, listing)
+		}
+		if v != nil {
+			r.Violations = append(r.Violations, *v)
+		}
+	}
+	sort.Slice(r.Violations, func(i, j int) bool {
+		a, b := r.Violations[i], r.Violations[j]
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		return a.Poa < b.Poa
+	})
+	return r
+}
+
+// Guard returns a *Violation if listing is priced below its limit, an error
+// wrapping ErrUncheckable if it cannot be compared, and nil otherwise. Call
+// it before publishing a price.
+func (l *Limits) Guard(listing Listing) error {
+	v, _, err := l.check(listing)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		return v
+	}
+	return nil
+}
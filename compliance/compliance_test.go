@@ -0,0 +1,114 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func money(s, cur string) client.Money {
+	m, err := client.ParseMoney(s, cur)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func testLimits() *Limits {
+	return NewLimits("USD", []Limit{
+		{BrandID: "1", BrandName: "Acme", ProductID: "100", LimitPrice: money("20", "")},
+		{BrandID: "1", BrandName: "Acme", ProductID: "100", Poa: "Red", LimitPrice: money("25", "")},
+		{BrandID: "1", BrandName: "Acme", ProductID: "100", Poa: " red ", LimitPrice: money("24", "")},
+		{BrandID: "2", BrandName: "Bolt", ProductID: "200", Sku: "B-200", LimitPrice: money("9.99", "")},
+	})
+}
+
+func TestLimitsLookup(t *testing.T) {
+	l := testLimits()
+	if l.Len() != 3 {
+		t.Errorf("Len = %d, want 3", l.Len())
+	}
+	tests := []struct {
+		productID, poa string
+		want           string
+		ok             bool
+	}{
+		{"100", "RED", "USD 25.00", true},
+		{"100", "Blue", "USD 20.00", true},
+		{"200", "", "USD 9.99", true},
+		{"300", "", "", false},
+	}
+	for _, tt := range tests {
+		limit, ok := l.Lookup(tt.productID, tt.poa)
+		if ok != tt.ok || ok && limit.LimitPrice.String() != tt.want {
+			t.Errorf("Lookup(%s, %s) = %v, %v; want %s, %v", tt.productID, tt.poa, limit.LimitPrice, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLimitsCheck(t *testing.T) {
+	report := testLimits().Check([]Listing{
+		{ProductID: "200", Price: money("9.50", "USD")},
+		{ProductID: "100", Poa: "Red", Price: money("24.99", "USD")},
+		{ProductID: "100", Poa: "Blue", Price: money("20", "USD")},
+		{ProductID: "100", Price: money("30", "EUR")},
+		{ProductID: "300", Price: money("1", "USD")},
+	})
+	if report.Checked != 5 || report.Limited != 4 {
+		t.Errorf("Checked %d, Limited %d", report.Checked, report.Limited)
+	}
+	if len(report.Violations) != 2 {
+		t.Fatalf("Violations = %+v", report.Violations)
+	}
+	v := report.Violations[0]
+	if v.ProductID != "100" || v.Delta.String() != "USD 0.01" || v.BrandName != "Acme" {
+		t.Errorf("first violation = %+v", v)
+	}
+	if v := report.Violations[1]; v.Sku != "B-200" || v.Delta.String() != "USD 0.49" {
+		t.Errorf("second violation = %+v", v)
+	}
+	if len(report.Unchecked) != 1 || report.Unchecked[0].Price.Currency != "EUR" {
+		t.Errorf("Unchecked = %+v", report.Unchecked)
+	}
+}
+
+func TestLimitsGuard(t *testing.T) {
+	l := testLimits()
+	err := l.Guard(Listing{ProductID: "200", Price: money("5", "USD")})
+	var v *Violation
+	if !errors.Is(err, ErrBelowLimitPrice) || !errors.As(err, &v) || v.LimitPrice.String() != "USD 9.99" {
+		t.Errorf("Guard = %v, want violation", err)
+	}
+	if err := l.Guard(Listing{ProductID: "200", Price: money("9.99", "USD")}); err != nil {
+		t.Errorf("Guard at the limit = %v", err)
+	}
+	if err := l.Guard(Listing{ProductID: "200", Price: money("5", "EUR")}); !errors.Is(err, ErrUncheckable) {
+		t.Errorf("Guard in EUR = %v, want ErrUncheckable", err)
+	}
+	if err := l.Guard(Listing{ProductID: "999", Price: money("1", "EUR")}); err != nil {
+		t.Errorf("Guard without limit = %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(1, 2, 5)
+	srv.Seed(catalog)
+
+	l, err := Load(context.Background(), srv.ManagedClient(), "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Brands) == 0 || len(catalog.Brands[0].Products) == 0 {
+		t.Fatal("catalog has no limit prices")
+	}
+	want := catalog.Brands[0].Products[0]
+	limit, ok := l.Lookup(want.ProductID, want.Poa)
+	if !ok || limit.BrandID != catalog.Brands[0].BrandID || limit.LimitPrice.Currency != "USD" {
+		t.Errorf("Lookup = %+v, %v", limit, ok)
+	}
+}
@@ -0,0 +1,73 @@
+package delivery
+
+import "time"
+
+const (
+	dateLayout = "2006-01-02"
+	// maxClosedDays bounds the search for the next business day; a
+	// calendar closed for longer is taken to have none.
+	maxClosedDays = 366
+)
+
+// Calendar knows which days a country's carriers deliver on.
+type Calendar struct {
+	Weekend  []time.Weekday
+	holidays map[string]bool
+}
+
+// NewCalendar returns a calendar with a Saturday and Sunday weekend and the
+// given holidays.
+func NewCalendar(holidays ...time.Time) *Calendar {
+	c := &Calendar{Weekend: []time.Weekday{time.Saturday, time.Sunday}}
+	c.AddHolidays(holidays...)
+	return c
+}
+
+func (c *Calendar) AddHolidays(days ...time.Time) {
+	if c.holidays == nil {
+		c.holidays = map[string]bool{}
+	}
+	for _, d := range days {
+		c.holidays[d.Format(dateLayout)] = true
+	}
+}
+
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	for _, d := range c.Weekend {
+		if t.Weekday() == d {
+			return false
+		}
+	}
+	return !c.holidays[t.Format(dateLayout)]
+}
+
+// nextBusinessDay returns the first business day from t on. It reports
+// false if none falls within maxClosedDays.
+func (c *Calendar) nextBusinessDay(t time.Time) (time.Time, bool) {
+	for i := 0; i < maxClosedDays; i++ {
+		if c.IsBusinessDay(t) {
+			return t, true
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, false
+}
+
+// AddBusinessDays returns the date n business days after t. A t that is
+// not a business day counts from the next one. Once the calendar runs out of
+// business days, such as when its weekend covers the whole week, the
+// remaining days are counted as calendar days.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	day, ok := c.nextBusinessDay(t)
+	if !ok {
+		return t.AddDate(0, 0, n)
+	}
+	for ; n > 0; n-- {
+		next, ok := c.nextBusinessDay(day.AddDate(0, 0, 1))
+		if !ok {
+			return day.AddDate(0, 0, n)
+		}
+		day = next
+	}
+	return day
+}
@@ -0,0 +1,58 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	// 2021-04-02 is a Friday and 2021-04-05 Easter Monday.
+	cal := NewCalendar(day("2021-04-05"))
+	tests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2021-03-29", 0, "2021-03-29"},
+		{"2021-03-29", 3, "2021-04-01"},
+		{"2021-04-01", 1, "2021-04-02"},
+		{"2021-04-02", 1, "2021-04-06"},
+		{"2021-04-03", 0, "2021-04-06"},
+		{"2021-04-03", 2, "2021-04-08"},
+	}
+	for _, tt := range tests {
+		if got := cal.AddBusinessDays(day(tt.from), tt.n).Format(dateLayout); got != tt.want {
+			t.Errorf("AddBusinessDays(%s, %d) = %s, want %s", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAddBusinessDaysWithoutBusinessDays(t *testing.T) {
+	closed := NewCalendar()
+	closed.Weekend = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	for n, want := range map[int]string{0: "2021-03-29", 1: "2021-03-30", 3: "2021-04-01"} {
+		if got := closed.AddBusinessDays(day("2021-03-29"), n).Format(dateLayout); got != want {
+			t.Errorf("closed calendar AddBusinessDays(2021-03-29, %d) = %s, want %s", n, got, want)
+		}
+	}
+
+	// Open on Mondays only, but every Monday after the first is a holiday.
+	mondays := NewCalendar()
+	mondays.Weekend = []time.Weekday{time.Sunday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	for d := day("2021-04-05"); d.Year() < 2023; d = d.AddDate(0, 0, 7) {
+		mondays.AddHolidays(d)
+	}
+	for n, want := range map[int]string{0: "2021-03-29", 1: "2021-03-30", 2: "2021-03-31"} {
+		if got := mondays.AddBusinessDays(day("2021-03-27"), n).Format(dateLayout); got != want {
+			t.Errorf("calendar closed after one day AddBusinessDays(2021-03-27, %d) = %s, want %s", n, got, want)
+		}
+	}
+}
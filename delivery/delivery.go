@@ -0,0 +1,263 @@
+// Package delivery estimates when orders will arrive.
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+const (
+	DefaultHandlingDays = 2
+	DefaultMinSamples   = 5
+)
+
+var ErrNotDelivered = errors.New("delivery: order has not been delivered")
+
+// Estimate is the delivery window of an order.
+type Estimate struct {
+	// Dispatched reports that DispatchDate comes from the order history
+	// rather than from the expected handling time.
+	Dispatched   bool      `json:"dispatched"`
+	DispatchDate time.Time `json:"dispatch_date"`
+	Earliest     time.Time `json:"earliest"`
+	Latest       time.Time `json:"latest"`
+	// Window is the transit time the estimate is based on; Learned reports
+	// that it comes from observed deliveries rather than Shipday.
+	Window  client.DeliveryWindow `json:"window"`
+	Learned bool                  `json:"learned"`
+}
+
+type route struct {
+	method, country string
+}
+
+// Estimator turns ship-day ranges into delivery dates, optionally refined by
+// transit times learned from delivered orders.
+type Estimator struct {
+	Client client.ManagedClient
+	// Calendars holds the business days of destination countries, keyed
+	// like the country parameter of GetShipments. Countries without a
+	// calendar use Default, or a Saturday and Sunday weekend if it is nil.
+	Calendars map[string]*Calendar
+	Default   *Calendar
+	// HandlingDays is the business days an order is expected to wait
+	// before dispatch.
+	HandlingDays int
+	// MinSamples is how many deliveries of a route must be observed before
+	// their transit times replace Shipday.
+	MinSamples int
+
+	mu      sync.Mutex
+	transit map[route][]int
+	now     func() time.Time
+}
+
+func NewEstimator(c client.ManagedClient) *Estimator {
+	return &Estimator{
+		Client:       c,
+		Calendars:    map[string]*Calendar{},
+		Default:      NewCalendar(),
+		HandlingDays: DefaultHandlingDays,
+		MinSamples:   DefaultMinSamples,
+		transit:      map[route][]int{},
+		now:          time.Now,
+	}
+}
+
+func (e *Estimator) clock() time.Time {
+	if e.now == nil {
+		return time.Now()
+	}
+	return e.now()
+}
+
+func (e *Estimator) calendar(country string) *Calendar {
+	if c, ok := e.Calendars[country]; ok && c != nil {
+		return c
+	}
+	if e.Default != nil {
+		return e.Default
+	}
+	return NewCalendar()
+}
+
+// Estimate returns the delivery window of a parcel dispatched on dispatch
+// to country with method. Learned transit times for the route take
+// precedence over window.
+func (e *Estimator) Estimate(dispatch time.Time, country, method string, window client.DeliveryWindow) Estimate {
+	est := Estimate{DispatchDate: dispatch, Window: window}
+	if learned, ok := e.Transit(method, country); ok {
+		est.Window, est.Learned = learned, true
+	}
+	if est.Window.BusinessDays {
+		cal := e.calendar(country)
+		est.Earliest = cal.AddBusinessDays(dispatch, est.Window.MinDays)
+		est.Latest = cal.AddBusinessDays(dispatch, est.Window.MaxDays)
+	} else {
+		est.Earliest = dispatch.AddDate(0, 0, est.Window.MinDays)
+		est.Latest = dispatch.AddDate(0, 0, est.Window.MaxDays)
+	}
+	return est
+}
+
+// EstimateOrder estimates the delivery of an imported order. The dispatch
+// date is read from the order history; orders that have not shipped yet are
+// expected to ship HandlingDays business days from now.
+func (e *Estimator) EstimateOrder(ctx context.Context, saleRecordID, orderID, country string, method client.ShipMethod) (Estimate, error) {
+	window, err := method.DeliveryWindow()
+	if err != nil {
+		return Estimate{}, err
+	}
+	history, err := e.Client.GetOrderHistory(ctx, saleRecordID, orderID)
+	if err != nil {
+		return Estimate{}, err
+	}
+	dispatch, dispatched, err := dispatchDate(history)
+	if err != nil {
+		return Estimate{}, err
+	}
+	if !dispatched {
+		handling := e.Default
+		if handling == nil {
+			handling = NewCalendar()
+		}
+		dispatch = handling.AddBusinessDays(e.clock(), e.HandlingDays)
+	}
+	est := e.Estimate(dispatch, country, method.ShipMethodCode, window)
+	est.Dispatched = dispatched
+	return est, nil
+}
+
+func statusIs(status string, words ...string) bool {
+	status = strings.ToLower(status)
+	for _, w := range words {
+		if strings.Contains(status, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchDate returns when the order history first reports the order as
+// shipped.
+func dispatchDate(history client.GetOrderHistoryResponse) (time.Time, bool, error) {
+	for _, h := range history.OrderHistory {
+		if !statusIs(h.Status, "shipped", "dispatched") {
+			continue
+		}
+		t, err := client.ParseBanggoodTime(h.DateAdd)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("delivery: order history date %q: %w", h.DateAdd, err)
+		}
+		return t.Time, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// deliveryDate returns the time of the last tracking event reporting
+// delivery, falling back to a Delivered status in the order history.
+func deliveryDate(track client.GetTrackInfoResponse, history client.GetOrderHistoryResponse) (time.Time, bool, error) {
+	for i := len(track.TrackInfo) - 1; i >= 0; i-- {
+		event := track.TrackInfo[i]
+		if !statusIs(event.Event, "delivered") {
+			continue
+		}
+		t, err := client.ParseBanggoodTime(event.Time)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("delivery: tracking event time %q: %w", event.Time, err)
+		}
+		return t.Time, true, nil
+	}
+	for _, h := range history.OrderHistory {
+		if !statusIs(h.Status, "delivered") {
+			continue
+		}
+		t, err := client.ParseBanggoodTime(h.DateAdd)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("delivery: order history date %q: %w", h.DateAdd, err)
+		}
+		return t.Time, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// Learn records the transit time of a delivered order, from its dispatch in
+// the order history to the delivery reported by its tracking events. It
+// returns ErrNotDelivered for orders still in transit.
+func (e *Estimator) Learn(ctx context.Context, saleRecordID, orderID, country, method string) (int, error) {
+	history, err := e.Client.GetOrderHistory(ctx, saleRecordID, orderID)
+	if err != nil {
+		return 0, err
+	}
+	track, err := e.Client.GetTrackInfo(ctx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	dispatched, ok, err := dispatchDate(history)
+	if err != nil {
+		return 0, err
+	}
+	delivered, ok2, err := deliveryDate(track, history)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || !ok2 {
+		return 0, fmt.Errorf("%w: order %s", ErrNotDelivered, orderID)
+	}
+	days := int(delivered.Sub(dispatched).Hours()/24 + 0.5)
+	if days < 0 {
+		days = 0
+	}
+	e.Observe(method, country, days)
+	return days, nil
+}
+
+// Observe records a transit time in calendar days for a ship method and
+// destination.
+func (e *Estimator) Observe(method, country string, days int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.transit == nil {
+		e.transit = map[route][]int{}
+	}
+	r := route{method, country}
+	e.transit[r] = append(e.transit[r], days)
+}
+
+// Transit returns the learned transit window of a route, spanning the 10th
+// to the 90th percentile of observed calendar days. It reports false until
+// MinSamples deliveries have been observed.
+func (e *Estimator) Transit(method, country string) (client.DeliveryWindow, bool) {
+	e.mu.Lock()
+	samples := append([]int(nil), e.transit[route{method, country}]...)
+	e.mu.Unlock()
+
+	min := e.MinSamples
+	if min < 1 {
+		min = 1
+	}
+	if len(samples) < min {
+		return client.DeliveryWindow{}, false
+	}
+	sort.Ints(samples)
+	return client.DeliveryWindow{
+		MinDays: percentile(samples, 10),
+		MaxDays: percentile(samples, 90),
+	}, true
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,115 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func TestEstimate(t *testing.T) {
+	e := NewEstimator(nil)
+	dispatch := day("2021-04-01")
+
+	est := e.Estimate(dispatch, "Germany", "airmail", client.DeliveryWindow{MinDays: 2, MaxDays: 5, BusinessDays: true})
+	if est.Earliest.Format(dateLayout) != "2021-04-05" || est.Latest.Format(dateLayout) != "2021-04-08" || est.Learned {
+		t.Errorf("business days = %+v", est)
+	}
+	est = e.Estimate(dispatch, "Germany", "airmail", client.DeliveryWindow{MinDays: 2, MaxDays: 5})
+	if est.Earliest.Format(dateLayout) != "2021-04-03" || est.Latest.Format(dateLayout) != "2021-04-06" {
+		t.Errorf("calendar days = %+v", est)
+	}
+
+	// Learned transit times replace Shipday once there are enough.
+	for _, days := range []int{8, 9, 10, 11, 30} {
+		e.Observe("airmail", "Germany", days)
+	}
+	est = e.Estimate(dispatch, "Germany", "airmail", client.DeliveryWindow{MinDays: 2, MaxDays: 5, BusinessDays: true})
+	if !est.Learned || est.Window.MinDays != 8 || est.Window.MaxDays != 30 || est.Latest.Format(dateLayout) != "2021-05-01" {
+		t.Errorf("learned = %+v", est)
+	}
+	if _, ok := e.Transit("airmail", "France"); ok {
+		t.Error("Transit reported a route without samples")
+	}
+}
+
+// shippedOrder imports a one-line order on srv and returns its sale record
+// and order IDs.
+func shippedOrder(t *testing.T, srv *banggoodtest.Server, c client.ManagedClient, p banggoodtest.Product) (string, string) {
+	t.Helper()
+	order := client.ImportOrderRequest{
+		SaleRecordID:          "SR-" + p.ProductID,
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          1,
+		ProductList: []client.ImportOrderProduct{{
+			ProductID:      p.ProductID,
+			Warehouse:      p.Stocks[0].Warehouse,
+			Quantity:       "1",
+			ShipmethodCode: p.Shipments[0].ShipMethodCode,
+		}},
+	}
+	srv.SetStock(p.ProductID, p.Stocks[0].Warehouse, p.Stocks[0].StocksList[0].PoaID, 10)
+	if _, err := c.ImportOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	ids := srv.OrderIDs(order.SaleRecordID)
+	if len(ids) != 1 {
+		t.Fatalf("orders = %v", ids)
+	}
+	return order.SaleRecordID, ids[0]
+}
+
+func TestLearnAndEstimateOrder(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(5, 1, 1)
+	srv.Seed(catalog)
+	c := srv.ManagedClient()
+	ctx := context.Background()
+	p := catalog.Products[0]
+	saleRecordID, orderID := shippedOrder(t, srv, c, p)
+
+	e := NewEstimator(c)
+	e.now = srv.Now
+	method := p.Shipments[0]
+	window, err := method.DeliveryWindow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	est, err := e.EstimateOrder(ctx, saleRecordID, orderID, "Germany", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Dispatched || est.DispatchDate.Before(srv.Now()) || est.Window != window {
+		t.Errorf("unshipped estimate = %+v", est)
+	}
+	if _, err := e.Learn(ctx, saleRecordID, orderID, "Germany", method.ShipMethodCode); !errors.Is(err, ErrNotDelivered) {
+		t.Errorf("Learn before delivery = %v, want ErrNotDelivered", err)
+	}
+
+	srv.SetOrderStatus(orderID, banggoodtest.StatusShipped)
+	shipped := srv.Now()
+	est, err = e.EstimateOrder(ctx, saleRecordID, orderID, "Germany", method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !est.Dispatched || est.DispatchDate.Sub(shipped) > time.Second || shipped.Sub(est.DispatchDate) > time.Second {
+		t.Errorf("shipped estimate = %+v, dispatched at %v", est, shipped)
+	}
+
+	srv.Advance(9 * 24 * time.Hour)
+	srv.AddTrackEvent(orderID, "LP123", "Delivered")
+	days, err := e.Learn(ctx, saleRecordID, orderID, "Germany", method.ShipMethodCode)
+	if err != nil || days != 9 {
+		t.Errorf("Learn = %d, %v; want 9", days, err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package fulfillment chooses the warehouse and ship method for an order.
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+var ErrNoOption = errors.New("fulfillment: no warehouse can fulfil the order")
+
+// Rank orders fulfilment options.
+type Rank int
+
+const (
+	// ByCost ranks the cheapest landed cost first, then the fastest
+	// delivery.
+	ByCost Rank = iota
+	// ByDelivery ranks the earliest latest-delivery day first, then the
+	// cheapest landed cost.
+	ByDelivery
+)
+
+// Request describes what to fulfil.
+type Request struct {
+	ProductID string
+	// PoaID is the comma-separated POA IDs of the variant, in any order.
+	PoaID    string
+	Country  string
+	Quantity int
+	// Currency defaults to the client's currency.
+	Currency string
+	// TrackedOnly discards ship methods without tracking.
+	TrackedOnly bool
+	// MaxDays, if positive, discards ship methods that may take longer.
+	MaxDays int
+}
+
+// reject returns why m does not meet the request, or "".
+func (r Request) reject(m client.ShipMethod) string {
+	delivery, err := m.DeliveryWindow()
+	switch {
+	case err != nil:
+		return fmt.Sprintf("unknown delivery time %q", m.Shipday)
+	case r.TrackedOnly && !m.Tracked():
+		return "not tracked"
+	case r.MaxDays > 0 && delivery.MaxDays > r.MaxDays:
+		return fmt.Sprintf("delivery takes up to %d days, more than %d", delivery.MaxDays, r.MaxDays)
+	}
+	return ""
+}
+
+// Option is one way to fulfil a request. Amounts are totals for the
+// requested quantity.
+type Option struct {
+	Warehouse      string                `json:"warehouse"`
+	ShipMethodCode string                `json:"shipmethod_code"`
+	ShipMethodName string                `json:"shipmethod_name"`
+	UnitPrice      client.Money          `json:"unit_price"`
+	ProductCost    client.Money          `json:"product_cost"`
+	Shipping       client.Money          `json:"shipping"`
+	LandedCost     client.Money          `json:"landed_cost"`
+	Delivery       client.DeliveryWindow `json:"delivery"`
+	Stock          int                   `json:"stock"`
+}
+
+// Discard explains why a warehouse or ship method was not offered.
+type Discard struct {
+	Warehouse      string `json:"warehouse"`
+	ShipMethodCode string `json:"shipmethod_code,omitempty"`
+	Reason         string `json:"reason"`
+}
+
+// Plan is the ranked options for a request and the discarded alternatives.
+type Plan struct {
+	Options   []Option  `json:"options"`
+	Discarded []Discard `json:"discarded"`
+}
+
+// Best returns the top-ranked option.
+func (p Plan) Best() (Option, bool) {
+	if len(p.Options) == 0 {
+		return Option{}, false
+	}
+	return p.Options[0], true
+}
+
+// Optimizer compares the warehouses of a product using live price, stock
+// and shipping data.
+type Optimizer struct {
+	Client client.ManagedClient
+	Rank   Rank
+}
+
+func NewOptimizer(c client.ManagedClient) *Optimizer {
+	return &Optimizer{Client: c}
+}
+
+func samePoa(a, b string) bool {
+	split := func(s string) []string {
+		var ids []string
+		for _, id := range strings.Split(s, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	return strings.Join(split(a), ",") == strings.Join(split(b), ",")
+}
+
+// Plan ranks every warehouse and ship method that can deliver the variant
+// to the destination. When options are priced in different currencies, those
+// not in the request's currency, or the first option's if the request has
+// none, are discarded rather than compared. It returns ErrNoOption along
+// with the discards when none can.
+func (o *Optimizer) Plan(ctx context.Context, req Request) (Plan, error) {
+	if req.Quantity < 1 {
+		return Plan{}, fmt.Errorf("fulfillment: quantity %d must be positive", req.Quantity)
+	}
+	var currency *string
+	if req.Currency != "" {
+		currency = &req.Currency
+	}
+	matrix, err := o.Client.GetVariantMatrix(ctx, req.ProductID, currency)
+	if err != nil {
+		return Plan{}, err
+	}
+	var variant *client.Variant
+	for i := range matrix.Variants {
+		if samePoa(matrix.Variants[i].PoaID(), req.PoaID) {
+			variant = &matrix.Variants[i]
+			break
+		}
+	}
+	if variant == nil {
+		return Plan{}, fmt.Errorf("fulfillment: product %s has no variant with poa_id %q", req.ProductID, req.PoaID)
+	}
+
+	var plan Plan
+	for _, w := range variant.Warehouses {
+		if w.Stock < req.Quantity {
+			plan.Discarded = append(plan.Discarded, Discard{
+				Warehouse: w.Warehouse,
+				Reason:    fmt.Sprintf("insufficient stock: %d available, %d requested", w.Stock, req.Quantity),
+			})
+			continue
+		}
+		shipments, err := o.Client.GetShipments(ctx, req.ProductID, w.Warehouse, req.Country, variant.PoaID(), req.Currency, req.Quantity)
+		// Banggood rejects destinations a warehouse cannot ship to as a bad
+		// parameter; anything else is a real failure.
+		var apiErr *client.APIError
+		if errors.Is(err, client.ErrBadParameter) && errors.As(err, &apiErr) {
+			plan.Discarded = append(plan.Discarded, Discard{
+				Warehouse: w.Warehouse,
+				Reason:    fmt.Sprintf("no shipping to %s: %s", req.Country, apiErr.Message),
+			})
+			continue
+		}
+		if err != nil {
+			return plan, err
+		}
+		if len(shipments.ShipMethods) == 0 {
+			plan.Discarded = append(plan.Discarded, Discard{
+				Warehouse: w.Warehouse,
+				Reason:    fmt.Sprintf("no ship methods to %s", req.Country),
+			})
+			continue
+		}
+		productCost := w.Price.Mul(int64(req.Quantity))
+		for _, m := range shipments.ShipMethods {
+			reason := req.reject(m)
+			if !w.Price.SameCurrency(m.Shipfee) {
+				reason = fmt.Sprintf("shipping quoted in %s, price in %s", m.Shipfee.Currency, w.Price.Currency)
+			}
+			if reason != "" {
+				plan.Discarded = append(plan.Discarded, Discard{
+					Warehouse:      w.Warehouse,
+					ShipMethodCode: m.ShipMethodCode,
+					Reason:         reason,
+				})
+				continue
+			}
+			delivery, _ := m.DeliveryWindow()
+			plan.Options = append(plan.Options, Option{
+				Warehouse:      w.Warehouse,
+				ShipMethodCode: m.ShipMethodCode,
+				ShipMethodName: m.ShipMethodName,
+				UnitPrice:      w.Price,
+				ProductCost:    productCost,
+				Shipping:       m.Shipfee,
+				LandedCost:     productCost.MustAdd(m.Shipfee),
+				Delivery:       delivery,
+				Stock:          w.Stock,
+			})
+		}
+	}
+
+	plan.keepCurrency(req.Currency)
+	o.rank(plan.Options)
+	if len(plan.Options) == 0 {
+		return plan, ErrNoOption
+	}
+	return plan, nil
+}
+
+// keepCurrency discards the options whose landed cost cannot be compared
+// with the rest: if the options are in different currencies, those not in
+// cur, or if cur is empty, not in the currency of the first option.
+func (p *Plan) keepCurrency(cur string) {
+	costs := make([]client.Money, len(p.Options))
+	for i, opt := range p.Options {
+		costs[i] = opt.LandedCost
+	}
+	if _, err := client.CheckCurrency(costs...); err == nil {
+		return
+	}
+	if cur == "" {
+		cur = p.Options[0].LandedCost.Currency
+	}
+	kept := p.Options[:0]
+	for _, opt := range p.Options {
+		if opt.LandedCost.SameCurrency(client.Money{Currency: cur}) {
+			kept = append(kept, opt)
+			continue
+		}
+		p.Discarded = append(p.Discarded, Discard{
+			Warehouse:      opt.Warehouse,
+			ShipMethodCode: opt.ShipMethodCode,
+			Reason:         fmt.Sprintf("landed cost in %s, other options in %s", opt.LandedCost.Currency, cur),
+		})
+	}
+	p.Options = kept
+}
+
+func (o *Optimizer) rank(options []Option) {
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		cost := a.LandedCost.MustCmp(b.LandedCost)
+		days := a.Delivery.MaxDays - b.Delivery.MaxDays
+		if days == 0 {
+			days = a.Delivery.MinDays - b.Delivery.MinDays
+		}
+		if o.Rank == ByDelivery && days != 0 {
+			return days < 0
+		}
+		if cost != 0 {
+			return cost < 0
+		}
+		if days != 0 {
+			return days < 0
+		}
+		return a.Warehouse < b.Warehouse
+	})
+}
@@ -0,0 +1,194 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func testServer(t *testing.T) (*banggoodtest.Server, banggoodtest.Product) {
+	t.Helper()
+	srv := banggoodtest.NewServer()
+	t.Cleanup(srv.Close)
+	catalog := banggoodtest.GenerateCatalog(3, 1, 4)
+	srv.Seed(catalog)
+	for _, p := range catalog.Products {
+		if len(p.Stocks) > 1 {
+			return srv, p
+		}
+	}
+	t.Fatal("catalog has no product stocked in several warehouses")
+	return nil, banggoodtest.Product{}
+}
+
+// variantOf returns the first variant of productID.
+func variantOf(t *testing.T, c client.ManagedClient, productID string) client.Variant {
+	t.Helper()
+	m, err := c.GetVariantMatrix(context.Background(), productID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.Variants[0]
+}
+
+func TestPlanRanksByCost(t *testing.T) {
+	srv, p := testServer(t)
+	c := srv.ManagedClient()
+	v := variantOf(t, c, p.ProductID)
+
+	plan, err := NewOptimizer(c).Plan(context.Background(), Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany", Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Options) < 2 {
+		t.Fatalf("Options = %+v", plan.Options)
+	}
+	for i := 1; i < len(plan.Options); i++ {
+		if plan.Options[i-1].LandedCost.MustCmp(plan.Options[i].LandedCost) > 0 {
+			t.Errorf("option %d costs %s, more than option %d at %s", i-1, plan.Options[i-1].LandedCost, i, plan.Options[i].LandedCost)
+		}
+	}
+	best, ok := plan.Best()
+	if !ok || best.LandedCost.MustCmp(best.ProductCost.MustAdd(best.Shipping)) != 0 {
+		t.Errorf("Best = %+v", best)
+	}
+}
+
+func TestPlanRanksByDelivery(t *testing.T) {
+	srv, p := testServer(t)
+	c := srv.ManagedClient()
+	v := variantOf(t, c, p.ProductID)
+
+	o := NewOptimizer(c)
+	o.Rank = ByDelivery
+	plan, err := o.Plan(context.Background(), Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany", Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(plan.Options); i++ {
+		if plan.Options[i-1].Delivery.MaxDays > plan.Options[i].Delivery.MaxDays {
+			t.Errorf("option %d takes %s, longer than option %d", i-1, plan.Options[i-1].Delivery, i)
+		}
+	}
+}
+
+func TestPlanDiscards(t *testing.T) {
+	srv, p := testServer(t)
+	c := srv.ManagedClient()
+	v := variantOf(t, c, p.ProductID)
+	ctx := context.Background()
+
+	empty := v.Warehouses[0].Warehouse
+	for _, o := range v.Options {
+		poaID, err := strconv.Atoi(o.PoaID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.SetStock(p.ProductID, empty, poaID, 0)
+	}
+
+	plan, err := NewOptimizer(c).Plan(ctx, Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany", Quantity: 1, MaxDays: 15})
+	if err != nil && !errors.Is(err, ErrNoOption) {
+		t.Fatal(err)
+	}
+	var stockDiscard, slowDiscard bool
+	for _, d := range plan.Discarded {
+		stockDiscard = stockDiscard || d.Warehouse == empty && strings.HasPrefix(d.Reason, "insufficient stock")
+		slowDiscard = slowDiscard || strings.HasPrefix(d.Reason, "delivery takes up to")
+	}
+	if !stockDiscard || !slowDiscard {
+		t.Errorf("Discarded = %+v", plan.Discarded)
+	}
+	for _, o := range plan.Options {
+		if o.Warehouse == empty || o.Delivery.MaxDays > 15 {
+			t.Errorf("option %+v should have been discarded", o)
+		}
+	}
+
+	if _, err := NewOptimizer(c).Plan(ctx, Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany", Quantity: 1000000}); !errors.Is(err, ErrNoOption) {
+		t.Errorf("Plan for a huge quantity = %v, want ErrNoOption", err)
+	}
+	if _, err := NewOptimizer(c).Plan(ctx, Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany"}); err == nil {
+		t.Error("Plan without quantity succeeded")
+	}
+}
+
+func TestPlanPropagatesClientErrors(t *testing.T) {
+	srv, p := testServer(t)
+	c := srv.ManagedClient()
+	v := variantOf(t, c, p.ProductID)
+	srv.InjectFault(client.EndpointGetShipments, banggoodtest.Fault{Code: client.CodeRateLimited, Message: "slow down"})
+
+	_, err := NewOptimizer(c).Plan(context.Background(), Request{ProductID: p.ProductID, PoaID: v.PoaID(), Country: "Germany", Quantity: 1})
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Errorf("Plan = %v, want ErrRateLimited", err)
+	}
+}
+
+// stubClient serves a fixed variant matrix and shipping quote.
+type stubClient struct {
+	client.ManagedClient
+	matrix    client.VariantMatrix
+	shipments client.GetShipmentsResponse
+}
+
+func (s stubClient) GetVariantMatrix(ctx context.Context, productID string, currency *string) (client.VariantMatrix, error) {
+	return s.matrix, nil
+}
+
+func (s stubClient) GetShipments(ctx context.Context, productID, warehouse, country, poaID, currency string, quantity int) (client.GetShipmentsResponse, error) {
+	return s.shipments, nil
+}
+
+func TestPlanDiscardsOtherCurrencies(t *testing.T) {
+	c := stubClient{
+		matrix: client.VariantMatrix{Variants: []client.Variant{{
+			ProductID:  "1",
+			Options:    []client.OptionValue{{PoaID: "10"}},
+			Warehouses: []client.VariantWarehouse{{Warehouse: "CN", Price: client.NewMoney(10, 0, "USD"), Stock: 5}},
+		}}},
+		shipments: client.GetShipmentsResponse{Currency: "EUR", ShipMethods: client.ShipMethods{
+			{ShipMethodCode: "airmail", Shipday: "7-20 business days", Shipfee: client.NewMoney(3, 0, "EUR")},
+		}},
+	}
+	plan, err := NewOptimizer(c).Plan(context.Background(), Request{ProductID: "1", PoaID: "10", Country: "Germany", Quantity: 1})
+	if !errors.Is(err, ErrNoOption) {
+		t.Fatalf("Plan = %v, want ErrNoOption", err)
+	}
+	if len(plan.Discarded) != 1 || plan.Discarded[0].Reason != "shipping quoted in EUR, price in USD" {
+		t.Errorf("Discarded = %+v", plan.Discarded)
+	}
+}
+
+func TestPlanDiscardsMixedCurrencies(t *testing.T) {
+	c := stubClient{
+		matrix: client.VariantMatrix{Variants: []client.Variant{{
+			ProductID: "1",
+			Options:   []client.OptionValue{{PoaID: "10"}},
+			Warehouses: []client.VariantWarehouse{
+				{Warehouse: "CN", Price: client.NewMoney(10, 0, "USD"), Stock: 5},
+				{Warehouse: "DE", Price: client.NewMoney(9, 0, "EUR"), Stock: 5},
+			},
+		}}},
+		shipments: client.GetShipmentsResponse{ShipMethods: client.ShipMethods{
+			{ShipMethodCode: "airmail", Shipday: "7-20 business days", Shipfee: client.NewMoney(3, 0, "")},
+		}},
+	}
+	for cur, want := range map[string]string{"": "CN", "EUR": "DE"} {
+		plan, err := NewOptimizer(c).Plan(context.Background(), Request{ProductID: "1", PoaID: "10", Country: "Germany", Quantity: 1, Currency: cur})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Options) != 1 || plan.Options[0].Warehouse != want {
+			t.Errorf("currency %q: options = %+v, want %s only", cur, plan.Options, want)
+		}
+		if len(plan.Discarded) != 1 || !strings.HasPrefix(plan.Discarded[0].Reason, "landed cost in ") {
+			t.Errorf("currency %q: discarded = %+v", cur, plan.Discarded)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+var (
+	ErrEntryExists      = errors.New("orders: journal entry already exists")
+	ErrEntryChanged     = errors.New("orders: journal entry changed since it was read")
+	ErrAlreadyImported  = errors.New("orders: sale record already imported")
+	ErrSubmitInProgress = errors.New("orders: sale record is being submitted")
+)
+
+// State is the journal's knowledge of a sale record.
+type State string
+
+const (
+	// StatePending means the order may have been sent and Banggood's
+	// answer is unknown.
+	StatePending State = "pending"
+	// StateImported means Banggood holds orders for the sale record.
+	StateImported State = "imported"
+	// StateFailed means Banggood did not import the sale record, so it is
+	// safe to submit again.
+	StateFailed State = "failed"
+)
+
+// Entry is the journal record of one sale record. Order never carries the
+// access token.
+type Entry struct {
+	SaleRecordID string                      `json:"sale_record_id"`
+	State        State                       `json:"state"`
+	Order        client.ImportOrderRequest   `json:"order"`
+	Response     *client.ImportOrderResponse `json:"response,omitempty"`
+	Error        string                      `json:"error,omitempty"`
+	Attempts     int                         `json:"attempts"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	UpdatedAt    time.Time                   `json:"updated_at"`
+	// Version counts the updates of the entry; Journal.Update uses it to
+	// detect concurrent writers.
+	Version int `json:"version"`
+}
+
+// Journal persists entries by sale record ID. Implementations backed by a
+// database should implement Create as an insert that fails on a duplicate
+// key and Update as an update conditional on the version. Implementations
+// must be safe for concurrent use.
+type Journal interface {
+	Get(ctx context.Context, saleRecordID string) (Entry, bool, error)
+	// Create stores e, failing with ErrEntryExists if an entry for the
+	// sale record already exists.
+	Create(ctx context.Context, e Entry) error
+	// Update stores e with its Version incremented if the stored entry
+	// still has e.Version, and fails with ErrEntryChanged otherwise.
+	Update(ctx context.Context, e Entry) error
+	// Pending returns the entries in StatePending.
+	Pending(ctx context.Context) ([]Entry, error)
+}
+
+type MemoryJournal struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: map[string]Entry{}}
+}
+
+func (j *MemoryJournal) Get(ctx context.Context, saleRecordID string) (Entry, bool, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	e, ok := j.entries[saleRecordID]
+	return e, ok, nil
+}
+
+func (j *MemoryJournal) Create(ctx context.Context, e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.entries[e.SaleRecordID]; ok {
+		return fmt.Errorf("%w: %s", ErrEntryExists, e.SaleRecordID)
+	}
+	j.entries[e.SaleRecordID] = e
+	return nil
+}
+
+func (j *MemoryJournal) Update(ctx context.Context, e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	stored, ok := j.entries[e.SaleRecordID]
+	if !ok || stored.Version != e.Version {
+		return fmt.Errorf("%w: %s", ErrEntryChanged, e.SaleRecordID)
+	}
+	e.Version++
+	j.entries[e.SaleRecordID] = e
+	return nil
+}
+
+func (j *MemoryJournal) Pending(ctx context.Context) ([]Entry, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	var pending []Entry
+	for _, e := range j.entries {
+		if e.State == StatePending {
+			pending = append(pending, e)
+		}
+	}
+	sort.Slice(pending, func(a, b int) bool { return pending[a].SaleRecordID < pending[b].SaleRecordID })
+	return pending, nil
+}
+
+const (
+	entryPrefix = "sr-"
+	entryExt    = ".json"
+	lockExt     = ".lock"
+
+	// lockStale is how old a lock file must be before it is taken to be
+	// left behind by a crashed process.
+	lockStale = 30 * time.Second
+	lockPoll  = 10 * time.Millisecond
+)
+
+// FileJournal keeps one JSON file per sale record under Dir. Create and
+// Update are atomic across processes sharing Dir.
+type FileJournal struct {
+	Dir string
+
+	mu sync.RWMutex
+}
+
+func NewFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileJournal{Dir: dir}, nil
+}
+
+func (j *FileJournal) path(saleRecordID string) string {
+	return filepath.Join(j.Dir, entryPrefix+url.QueryEscape(saleRecordID)+entryExt)
+}
+
+// writeTemp writes e to a temporary file in Dir and returns its name.
+func (j *FileJournal) writeTemp(e Entry) (string, error) {
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(j.Dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func (j *FileJournal) read(path string) (Entry, bool, error) {
+	var e Entry
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return e, false, nil
+	}
+	if err != nil {
+		return e, false, err
+	}
+	return e, true, json.Unmarshal(b, &e)
+}
+
+func (j *FileJournal) Get(ctx context.Context, saleRecordID string) (Entry, bool, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.read(j.path(saleRecordID))
+}
+
+// Create links a fully written temporary file into place, which fails if
+// the entry exists, so readers never see a partial entry.
+func (j *FileJournal) Create(ctx context.Context, e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	tmp, err := j.writeTemp(e)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, j.path(e.SaleRecordID)); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w: %s", ErrEntryExists, e.SaleRecordID)
+		}
+		return err
+	}
+	return nil
+}
+
+// lock takes the lock file of a sale record, which serialises updates
+// between processes. It breaks locks older than lockStale.
+func (j *FileJournal) lock(ctx context.Context, saleRecordID string) (func(), error) {
+	path := j.path(saleRecordID) + lockExt
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// Update compares versions under the sale record's lock file and renames a
+// fully written temporary file into place.
+func (j *FileJournal) Update(ctx context.Context, e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	unlock, err := j.lock(ctx, e.SaleRecordID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, ok, err := j.read(j.path(e.SaleRecordID))
+	if err != nil {
+		return err
+	}
+	if !ok || stored.Version != e.Version {
+		return fmt.Errorf("%w: %s", ErrEntryChanged, e.SaleRecordID)
+	}
+	e.Version++
+	tmp, err := j.writeTemp(e)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, j.path(e.SaleRecordID)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (j *FileJournal) Pending(ctx context.Context) ([]Entry, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	files, err := ioutil.ReadDir(j.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Entry
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, entryPrefix) || !strings.HasSuffix(name, entryExt) {
+			continue
+		}
+		e, ok, err := j.read(filepath.Join(j.Dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if ok && e.State == StatePending {
+			pending = append(pending, e)
+		}
+	}
+	sort.Slice(pending, func(a, b int) bool { return pending[a].SaleRecordID < pending[b].SaleRecordID })
+	return pending, nil
+}
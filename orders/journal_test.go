@@ -0,0 +1,119 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testJournals(t *testing.T) map[string]Journal {
+	t.Helper()
+	fj, err := NewFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Journal{"memory": NewMemoryJournal(), "file": fj}
+}
+
+func TestJournal(t *testing.T) {
+	ctx := context.Background()
+	for name, j := range testJournals(t) {
+		e := Entry{SaleRecordID: "SR/1 &x", State: StatePending, Order: testOrder(line("1", "CN", "1"))}
+		if err := j.Create(ctx, e); err != nil {
+			t.Fatalf("%s: Create = %v", name, err)
+		}
+		if err := j.Create(ctx, e); !errors.Is(err, ErrEntryExists) {
+			t.Errorf("%s: second Create = %v, want ErrEntryExists", name, err)
+		}
+
+		got, ok, err := j.Get(ctx, e.SaleRecordID)
+		if err != nil || !ok || got.State != StatePending || got.Version != 0 {
+			t.Fatalf("%s: Get = %+v, %v, %v", name, got, ok, err)
+		}
+		got.State = StateImported
+		if err := j.Update(ctx, got); err != nil {
+			t.Fatalf("%s: Update = %v", name, err)
+		}
+		// got still carries the version it was read at.
+		if err := j.Update(ctx, got); !errors.Is(err, ErrEntryChanged) {
+			t.Errorf("%s: stale Update = %v, want ErrEntryChanged", name, err)
+		}
+		if err := j.Update(ctx, Entry{SaleRecordID: "unknown"}); !errors.Is(err, ErrEntryChanged) {
+			t.Errorf("%s: Update of a missing entry = %v, want ErrEntryChanged", name, err)
+		}
+		if got, _, _ := j.Get(ctx, e.SaleRecordID); got.State != StateImported || got.Version != 1 {
+			t.Errorf("%s: after Update = %+v", name, got)
+		}
+
+		if err := j.Create(ctx, Entry{SaleRecordID: "SR-2", State: StatePending}); err != nil {
+			t.Fatal(err)
+		}
+		pending, err := j.Pending(ctx)
+		if err != nil || len(pending) != 1 || pending[0].SaleRecordID != "SR-2" {
+			t.Errorf("%s: Pending = %+v, %v", name, pending, err)
+		}
+	}
+}
+
+func TestFileJournalReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	j, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Create(ctx, Entry{SaleRecordID: "SR-1", State: StatePending, Attempts: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := reopened.Pending(ctx)
+	if err != nil || len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("Pending = %+v, %v", pending, err)
+	}
+	if err := reopened.Create(ctx, Entry{SaleRecordID: "SR-1"}); !errors.Is(err, ErrEntryExists) {
+		t.Errorf("Create after reopening = %v, want ErrEntryExists", err)
+	}
+}
+
+func TestFileJournalLock(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Entry{SaleRecordID: "SR-1", State: StatePending}
+	if err := j.Create(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process holds the lock.
+	lock := j.path(e.SaleRecordID) + lockExt
+	if err := ioutil.WriteFile(lock, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := j.Update(short, e); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Update while locked = %v, want DeadlineExceeded", err)
+	}
+
+	// It crashed long ago.
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Update(ctx, e); err != nil {
+		t.Errorf("Update past a stale lock = %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(j.Dir, "*"+lockExt)); len(matches) != 0 {
+		t.Errorf("lock files left behind: %v", matches)
+	}
+}
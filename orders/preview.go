@@ -0,0 +1,238 @@
+// Package orders prices and submits Banggood orders.
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// Reasons a line would fail, matching Banggood's failure_list wording where
+// it has one.
+const (
+	ProblemUnavailable       = "product is not available"
+	ProblemInvalidShipMethod = "invalid shipmethod_code"
+	ProblemOutOfStock        = "out of stock"
+)
+
+// LinePreview is the expected outcome of one order line. Amounts are totals
+// for the line's quantity.
+type LinePreview struct {
+	Index      int                       `json:"index"`
+	Line       client.ImportOrderProduct `json:"line"`
+	Warehouse  string                    `json:"warehouse"`
+	Quantity   int                       `json:"quantity"`
+	Stock      int                       `json:"stock"`
+	UnitPrice  client.Money              `json:"unit_price"`
+	Subtotal   client.Money              `json:"subtotal"`
+	ShipMethod client.ShipMethod         `json:"ship_method"`
+	Shipping   client.Money              `json:"shipping"`
+	Total      client.Money              `json:"total"`
+	// Problems lists why the line would land in the failure list.
+	Problems []string `json:"problems,omitempty"`
+}
+
+func (l LinePreview) OK() bool {
+	return len(l.Problems) == 0
+}
+
+func (l *LinePreview) fail(problem string) {
+	l.Problems = append(l.Problems, problem)
+}
+
+// Preview is the expected cost of an order. Totals cover only the lines
+// that would succeed.
+type Preview struct {
+	Currency string        `json:"currency"`
+	Lines    []LinePreview `json:"lines"`
+	Subtotal client.Money  `json:"subtotal"`
+	Shipping client.Money  `json:"shipping"`
+	Total    client.Money  `json:"total"`
+	Failures int           `json:"failures"`
+}
+
+// OK reports whether every line would succeed.
+func (p Preview) OK() bool {
+	return p.Failures == 0
+}
+
+// unavailable reports whether err is Banggood refusing the request's
+// parameters, as it does for unknown products and unshippable destinations.
+func unavailable(err error) (string, bool) {
+	var apiErr *client.APIError
+	if errors.Is(err, client.ErrBadParameter) && errors.As(err, &apiErr) {
+		return apiErr.Message, true
+	}
+	return "", false
+}
+
+// PreviewOrder prices every line of order and checks its stock and ship
+// method without importing it. Lines that would fail are flagged rather than
+// reported as errors; an error means the preview itself could not be made.
+func PreviewOrder(ctx context.Context, c client.ManagedClient, order client.ImportOrderRequest) (Preview, error) {
+	if err := order.Validate(); err != nil {
+		return Preview{}, err
+	}
+	p := Preview{Currency: order.Currency}
+
+	// reserved tracks the stock taken by earlier lines, by product,
+	// warehouse and POA.
+	reserved := map[string]int{}
+	stocks := map[string]client.GetStockResponse{}
+
+	for i, line := range order.ProductList {
+		lp := LinePreview{Index: i, Line: line, Warehouse: line.Warehouse}
+		lp.Quantity, _ = strconv.Atoi(strings.TrimSpace(line.Quantity))
+
+		stock, ok := stocks[line.ProductID]
+		if !ok {
+			var err error
+			stock, err = c.GetStock(ctx, line.ProductID)
+			if _, bad := unavailable(err); bad {
+				lp.fail(ProblemUnavailable)
+				p.add(lp)
+				continue
+			}
+			if err != nil {
+				return p, fmt.Errorf("line %d: %w", i, err)
+			}
+			stocks[line.ProductID] = stock
+		}
+		warehouse, available := pickWarehouse(stock, line, lp.Quantity, reserved)
+		if warehouse == "" {
+			lp.Stock = available
+			lp.fail(ProblemOutOfStock)
+			p.add(lp)
+			continue
+		}
+		lp.Warehouse, lp.Stock = warehouse, available
+
+		price, err := c.GetProductPrice(ctx, line.ProductID, line.PoaID, warehouse, order.Currency)
+		if _, bad := unavailable(err); bad {
+			lp.fail(ProblemUnavailable)
+			p.add(lp)
+			continue
+		}
+		if err != nil {
+			return p, fmt.Errorf("line %d: %w", i, err)
+		}
+		lp.UnitPrice = price.ProductPrice
+		lp.Subtotal = price.ProductPrice.Mul(int64(lp.Quantity))
+
+		shipments, err := c.GetShipments(ctx, line.ProductID, warehouse, order.DeliveryCountry, line.PoaID, order.Currency, lp.Quantity)
+		if msg, bad := unavailable(err); bad {
+			lp.fail(fmt.Sprintf("cannot ship to %s: %s", order.DeliveryCountry, msg))
+			p.add(lp)
+			continue
+		}
+		if err != nil {
+			return p, fmt.Errorf("line %d: %w", i, err)
+		}
+		method, ok := shipments.ShipMethods.Lookup(line.ShipmethodCode)
+		if !ok {
+			lp.fail(ProblemInvalidShipMethod)
+			p.add(lp)
+			continue
+		}
+		lp.ShipMethod = method
+		lp.Shipping = method.Shipfee
+		if !lp.Subtotal.SameCurrency(lp.Shipping) {
+			lp.fail(fmt.Sprintf("shipping quoted in %s, price in %s", lp.Shipping.Currency, lp.Subtotal.Currency))
+			p.add(lp)
+			continue
+		}
+		lp.Total = lp.Subtotal.MustAdd(lp.Shipping)
+		reserve(reserved, line.ProductID, warehouse, line.PoaID, lp.Quantity)
+		p.add(lp)
+	}
+	return p, nil
+}
+
+func (p *Preview) add(lp LinePreview) {
+	if lp.OK() && !lp.Total.SameCurrency(client.Money{Currency: p.Currency}) {
+		lp.fail(fmt.Sprintf("priced in %s, order in %s", lp.Total.Currency, p.Currency))
+	}
+	p.Lines = append(p.Lines, lp)
+	if !lp.OK() {
+		p.Failures++
+		return
+	}
+	p.Subtotal = p.Subtotal.MustAdd(lp.Subtotal)
+	p.Shipping = p.Shipping.MustAdd(lp.Shipping)
+	p.Total = p.Total.MustAdd(lp.Total)
+	if p.Currency == "" {
+		p.Currency = p.Total.Currency
+	}
+}
+
+func poaIDs(poaID string) []string {
+	var ids []string
+	for _, id := range strings.Split(poaID, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func stockKey(productID, warehouse, poaID string) string {
+	return productID + "|" + warehouse + "|" + poaID
+}
+
+func reserve(reserved map[string]int, productID, warehouse, poaID string, quantity int) {
+	ids := poaIDs(poaID)
+	if len(ids) == 0 {
+		ids = []string{""}
+	}
+	for _, id := range ids {
+		reserved[stockKey(productID, warehouse, id)] += quantity
+	}
+}
+
+// available returns the unreserved stock of a variant in one warehouse: the
+// lowest stock of its POAs, or the warehouse total without POAs.
+func available(w client.WarehouseStock, productID, poaID string, reserved map[string]int) int {
+	counts := map[string]int{}
+	total := 0
+	for _, s := range w.StocksList {
+		n, _ := strconv.Atoi(strings.TrimSpace(s.Stock))
+		counts[strconv.Itoa(s.PoaID)] += n
+		total += n
+	}
+	ids := poaIDs(poaID)
+	if len(ids) == 0 {
+		return total - reserved[stockKey(productID, w.Warehouse, "")]
+	}
+	lowest := -1
+	for _, id := range ids {
+		n := counts[id] - reserved[stockKey(productID, w.Warehouse, id)]
+		if lowest < 0 || n < lowest {
+			lowest = n
+		}
+	}
+	return lowest
+}
+
+// pickWarehouse returns the line's warehouse, or the first with enough
+// stock when the line names none, along with its available stock. It
+// returns an empty warehouse when the stock does not cover quantity.
+func pickWarehouse(stock client.GetStockResponse, line client.ImportOrderProduct, quantity int, reserved map[string]int) (string, int) {
+	best := 0
+	for _, w := range stock.Stocks {
+		if line.Warehouse != "" && w.Warehouse != line.Warehouse {
+			continue
+		}
+		n := available(w, line.ProductID, line.PoaID, reserved)
+		if n >= quantity {
+			return w.Warehouse, n
+		}
+		if n > best {
+			best = n
+		}
+	}
+	return "", best
+}
@@ -0,0 +1,149 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// testProduct is sold from CN and US in one colour, with stock of 5 in CN
+// and 2 in US.
+func testProduct(productID string) banggoodtest.Product {
+	return banggoodtest.Product{
+		Product: client.Product{ProductID: productID, CategoryID: 1, ProductName: "Product " + productID},
+		Info: client.GetProductInfoResponse{
+			WarehouseList: []client.ProductWarehouse{
+				{Warehouse: "CN", WarehousePrice: client.NewMoney(1000, 2, "USD")},
+				{Warehouse: "US", WarehousePrice: client.NewMoney(1200, 2, "USD")},
+			},
+			PoaList: []client.ProductOption{{OptionID: 1, OptionName: "Color", OptionValues: []client.OptionValue{
+				{PoaID: "100", Poa: "Black", PoaPrice: client.NewMoney(0, 0, "USD")},
+			}}},
+		},
+		Stocks: []client.WarehouseStock{
+			{Warehouse: "CN", StocksList: []client.PoaStock{{PoaID: 100, Stock: "5"}}},
+			{Warehouse: "US", StocksList: []client.PoaStock{{PoaID: 100, Stock: "2"}}},
+		},
+		Shipments: []client.ShipMethod{{ShipMethodCode: "airmail", Shipday: "7-20 business days", Shipfee: client.NewMoney(350, 2, "USD")}},
+	}
+}
+
+func testOrder(lines ...client.ImportOrderProduct) client.ImportOrderRequest {
+	return client.ImportOrderRequest{
+		SaleRecordID:          "SR-1",
+		DeliveryName:          "Max Mustermann",
+		DeliveryCountry:       "Germany",
+		DeliveryCity:          "Berlin",
+		DeliveryStreetAddress: "Hauptstr. 1",
+		DeliveryPostcode:      "10115",
+		DeliveryTelephone:     "0301234567",
+		ProductTotal:          len(lines),
+		ProductList:           lines,
+	}
+}
+
+func line(productID, warehouse, quantity string) client.ImportOrderProduct {
+	return client.ImportOrderProduct{ProductID: productID, PoaID: "100", Warehouse: warehouse, Quantity: quantity, ShipmethodCode: "airmail"}
+}
+
+func previewServer(t *testing.T, products ...banggoodtest.Product) client.ManagedClient {
+	t.Helper()
+	srv := banggoodtest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(banggoodtest.Catalog{Products: products})
+	return srv.ManagedClient()
+}
+
+func TestPreviewOrder(t *testing.T) {
+	c := previewServer(t, testProduct("1"))
+	p, err := PreviewOrder(context.Background(), c, testOrder(line("1", "CN", "2"), line("1", "", "3")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.OK() || len(p.Lines) != 2 {
+		t.Fatalf("Preview = %+v", p)
+	}
+	if l := p.Lines[0]; l.Subtotal.String() != "USD 20.00" || l.Total.String() != "USD 23.50" || l.Stock != 5 {
+		t.Errorf("line 0 = %+v", l)
+	}
+	// The first line leaves 3 in CN, which covers the second.
+	if l := p.Lines[1]; l.Warehouse != "CN" || l.Stock != 3 {
+		t.Errorf("line 1 = %+v", l)
+	}
+	if p.Currency != "USD" || p.Subtotal.String() != "USD 50.00" || p.Shipping.String() != "USD 7.00" || p.Total.String() != "USD 57.00" {
+		t.Errorf("totals = %s %s %s %s", p.Currency, p.Subtotal, p.Shipping, p.Total)
+	}
+}
+
+func TestPreviewOrderProblems(t *testing.T) {
+	c := previewServer(t, testProduct("1"), testProduct("2"))
+	bad := line("2", "CN", "1")
+	bad.ShipmethodCode = "teleport"
+	p, err := PreviewOrder(context.Background(), c, testOrder(
+		line("1", "US", "3"),
+		line("404", "", "1"),
+		bad,
+		line("1", "CN", "1"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ProblemOutOfStock, ProblemUnavailable, ProblemInvalidShipMethod, ""}
+	for i, problem := range want {
+		l := p.Lines[i]
+		if problem == "" && !l.OK() || problem != "" && (len(l.Problems) != 1 || l.Problems[0] != problem) {
+			t.Errorf("line %d problems = %v, want %q", i, l.Problems, problem)
+		}
+	}
+	if p.Failures != 3 || p.Total.String() != "USD 13.50" {
+		t.Errorf("Failures %d, Total %s", p.Failures, p.Total)
+	}
+	if p.Lines[0].Stock != 2 {
+		t.Errorf("out of stock line reports stock %d, want 2", p.Lines[0].Stock)
+	}
+}
+
+func TestPreviewOrderCurrencies(t *testing.T) {
+	eur := testProduct("1")
+	eur.Price = client.GetProductPriceResponse{Currency: "EUR", ProductPrice: client.NewMoney(900, 2, "EUR")}
+	c := previewServer(t, eur, testProduct("2"))
+
+	// The server quotes shipping in USD, so the EUR-priced line cannot be
+	// totalled.
+	p, err := PreviewOrder(context.Background(), c, testOrder(line("1", "CN", "1"), line("2", "CN", "1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := p.Lines[0]; len(l.Problems) != 1 || l.Problems[0] != "shipping quoted in USD, price in EUR" {
+		t.Errorf("line 0 problems = %v", l.Problems)
+	}
+	if !p.Lines[1].OK() || p.Failures != 1 || p.Total.String() != "USD 13.50" {
+		t.Errorf("Preview = %+v", p)
+	}
+
+	// Without an order currency, the first priced line sets it.
+	var mixed Preview
+	mixed.add(LinePreview{Total: client.NewMoney(10, 0, "USD")})
+	mixed.add(LinePreview{Total: client.NewMoney(10, 0, "EUR")})
+	if l := mixed.Lines[1]; mixed.Failures != 1 || len(l.Problems) != 1 || l.Problems[0] != "priced in EUR, order in USD" {
+		t.Errorf("mixed = %+v", mixed)
+	}
+}
+
+func TestPreviewOrderErrors(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{testProduct("1")}})
+	c := srv.ManagedClient()
+
+	if _, err := PreviewOrder(context.Background(), c, testOrder()); !errors.Is(err, client.ErrInvalidOrder) {
+		t.Errorf("PreviewOrder without lines = %v, want ErrInvalidOrder", err)
+	}
+	srv.InjectFault(client.EndpointGetStocks, banggoodtest.Fault{Code: client.CodeRateLimited, Message: "slow down"})
+	if _, err := PreviewOrder(context.Background(), c, testOrder(line("1", "CN", "1"))); !errors.Is(err, client.ErrRateLimited) {
+		t.Errorf("PreviewOrder = %v, want ErrRateLimited", err)
+	}
+}
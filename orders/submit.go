@@ -0,0 +1,236 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// DefaultStaleAfter is how long a pending entry is taken to be in flight.
+const DefaultStaleAfter = 10 * time.Minute
+
+// Submitter imports orders at most once per sale record. Every order is
+// journaled before it is sent, and an unanswered import is checked against
+// GetOrderInfo before the sale record is sent again.
+type Submitter struct {
+	Client  client.ManagedClient
+	Journal Journal
+	// StaleAfter is how long after its last update a pending entry is
+	// taken to be abandoned rather than in flight; zero means
+	// DefaultStaleAfter.
+	StaleAfter time.Duration
+
+	now func() time.Time
+}
+
+func NewSubmitter(c client.ManagedClient, j Journal) *Submitter {
+	return &Submitter{Client: c, Journal: j, StaleAfter: DefaultStaleAfter, now: time.Now}
+}
+
+func (s *Submitter) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+func (s *Submitter) stale(e Entry) bool {
+	after := s.StaleAfter
+	if after <= 0 {
+		after = DefaultStaleAfter
+	}
+	return s.clock().Sub(e.UpdatedAt) >= after
+}
+
+// Submit imports order unless its sale record was imported before, in which
+// case it returns the earlier response, if known, and ErrAlreadyImported.
+// An import whose response was lost but which GetOrderInfo shows landed is
+// reported the same way.
+// The order is sent once, without an IdempotencyKey, so the client never
+// retries it. When the outcome of the import cannot be determined, the
+// entry stays pending and Submit returns the import error. Until the entry
+// is stale, Submit refuses the sale record with ErrSubmitInProgress;
+// Reconcile resolves it sooner.
+func (s *Submitter) Submit(ctx context.Context, order client.ImportOrderRequest) (client.ImportOrderResponse, error) {
+	if err := order.Validate(); err != nil {
+		return client.ImportOrderResponse{}, err
+	}
+	order.AccessToken = ""
+	order.IdempotencyKey = ""
+
+	e, ok, err := s.Journal.Get(ctx, order.SaleRecordID)
+	if err != nil {
+		return client.ImportOrderResponse{}, err
+	}
+	if !ok {
+		now := s.clock()
+		e = Entry{
+			SaleRecordID: order.SaleRecordID,
+			State:        StatePending,
+			Order:        order,
+			Attempts:     1,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := s.Journal.Create(ctx, e); err != nil {
+			if errors.Is(err, ErrEntryExists) {
+				return client.ImportOrderResponse{}, inProgress(order.SaleRecordID)
+			}
+			return client.ImportOrderResponse{}, err
+		}
+		return s.send(ctx, e)
+	}
+
+	switch e.State {
+	case StateImported:
+		return alreadyImported(e)
+	case StatePending:
+		if !s.stale(e) {
+			return client.ImportOrderResponse{}, inProgress(order.SaleRecordID)
+		}
+		if e, err = s.reconcile(ctx, e); err != nil {
+			return client.ImportOrderResponse{}, claimError(err, order.SaleRecordID)
+		}
+		if e.State == StateImported {
+			return alreadyImported(e)
+		}
+	}
+
+	// Claim the entry; a concurrent Submit that got there first makes the
+	// update fail.
+	e.State = StatePending
+	e.Order = order
+	e.Error = ""
+	e.Attempts++
+	if err := s.update(ctx, &e); err != nil {
+		return client.ImportOrderResponse{}, claimError(err, order.SaleRecordID)
+	}
+	return s.send(ctx, e)
+}
+
+func inProgress(saleRecordID string) error {
+	return fmt.Errorf("%w: %s", ErrSubmitInProgress, saleRecordID)
+}
+
+// claimError reports losing a journal update to another writer as
+// ErrSubmitInProgress.
+func claimError(err error, saleRecordID string) error {
+	if errors.Is(err, ErrEntryChanged) {
+		return inProgress(saleRecordID)
+	}
+	return err
+}
+
+func alreadyImported(e Entry) (client.ImportOrderResponse, error) {
+	var res client.ImportOrderResponse
+	if e.Response != nil {
+		res = *e.Response
+	}
+	return res, fmt.Errorf("%w: %s", ErrAlreadyImported, e.SaleRecordID)
+}
+
+// update stores e and advances its version to match the journal.
+func (s *Submitter) update(ctx context.Context, e *Entry) error {
+	e.UpdatedAt = s.clock()
+	if err := s.Journal.Update(ctx, *e); err != nil {
+		return err
+	}
+	e.Version++
+	return nil
+}
+
+// send imports the pending entry e, which the caller has claimed, and
+// records the outcome. An import that may have landed is checked with
+// GetOrderInfo straight away.
+func (s *Submitter) send(ctx context.Context, e Entry) (client.ImportOrderResponse, error) {
+	res, err := s.Client.ImportOrder(ctx, e.Order)
+	switch {
+	case err == nil:
+		e.Response = &res
+		e.State = StateImported
+		if res.SuccessTotal == "0" {
+			// Banggood keeps no sale record when every line fails.
+			e.State = StateFailed
+		}
+	case errors.Is(err, client.ErrDuplicateOrder):
+		e.State, e.Error = StateImported, err.Error()
+		if uerr := s.update(ctx, &e); uerr != nil {
+			return res, uerr
+		}
+		return alreadyImported(e)
+	case ambiguous(err):
+		e.Error = err.Error()
+		if ctx.Err() == nil {
+			if resolved, rerr := s.reconcile(ctx, e); rerr == nil {
+				e = resolved
+			}
+		}
+		if e.State == StateImported {
+			return alreadyImported(e)
+		}
+	default:
+		e.State, e.Error = StateFailed, err.Error()
+	}
+	if uerr := s.update(ctx, &e); uerr != nil && err == nil {
+		return res, uerr
+	}
+	return res, err
+}
+
+// ambiguous reports whether the import may have reached Banggood despite
+// err.
+func ambiguous(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, client.ErrInvalidOrder) &&
+			!errors.Is(err, client.ErrSandboxProduction) &&
+			!errors.Is(err, client.ErrEmptyAccessToken)
+	}
+	return apiErr.HTTPStatus >= http.StatusInternalServerError || apiErr.Code == client.CodeSystemBusy
+}
+
+// reconcile asks Banggood whether the pending entry e landed. It marks the
+// entry imported if it did; otherwise the entry stays pending so it can be
+// sent again.
+func (s *Submitter) reconcile(ctx context.Context, e Entry) (Entry, error) {
+	info, err := s.Client.GetOrderInfo(ctx, e.SaleRecordID)
+	if err != nil {
+		return e, err
+	}
+	for _, sr := range info.SaleRecordIDList {
+		if sr.SaleRecordID == e.SaleRecordID && len(sr.OrderList) > 0 {
+			e.State = StateImported
+			return e, s.update(ctx, &e)
+		}
+	}
+	return e, nil
+}
+
+// Reconcile checks every pending entry against GetOrderInfo and returns the
+// entries it found imported. Entries Banggood does not know are left
+// pending for Submit to send again once they are stale.
+func (s *Submitter) Reconcile(ctx context.Context) ([]Entry, error) {
+	pending, err := s.Journal.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var imported []Entry
+	for _, e := range pending {
+		e, err := s.reconcile(ctx, e)
+		if errors.Is(err, ErrEntryChanged) {
+			// Another Submit resolved the entry meanwhile.
+			continue
+		}
+		if err != nil {
+			return imported, err
+		}
+		if e.State == StateImported {
+			imported = append(imported, e)
+		}
+	}
+	return imported, nil
+}
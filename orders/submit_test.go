@@ -0,0 +1,241 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// dropImports forwards requests to the server but loses the response of
+// every importOrder, as a connection reset after the write would.
+type dropImports struct {
+	next http.RoundTripper
+}
+
+func (d dropImports) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := d.next.RoundTrip(req)
+	if err != nil || !strings.HasSuffix(req.URL.Path, client.EndpointImportOrder) {
+		return res, err
+	}
+	res.Body.Close()
+	return nil, errors.New("connection reset by peer")
+}
+
+// eagerRetry retries everything a transport or server error could cause,
+// without waiting.
+var eagerRetry = client.RetryPolicy{
+	MaxAttempts:     4,
+	RetryableCodes:  []int{client.CodeSystemBusy},
+	RetryableStatus: []int{http.StatusServiceUnavailable},
+}
+
+func submitServer(t *testing.T) *banggoodtest.Server {
+	t.Helper()
+	srv := banggoodtest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(banggoodtest.Catalog{Products: []banggoodtest.Product{testProduct("1")}})
+	return srv
+}
+
+func entryOf(t *testing.T, j Journal, saleRecordID string) Entry {
+	t.Helper()
+	e, ok, err := j.Get(context.Background(), saleRecordID)
+	if err != nil || !ok {
+		t.Fatalf("Get(%s) = %v, %v", saleRecordID, ok, err)
+	}
+	return e
+}
+
+func TestSubmitOnce(t *testing.T) {
+	srv := submitServer(t)
+	j := NewMemoryJournal()
+	s := NewSubmitter(srv.ManagedClient(client.WithRetryPolicy(eagerRetry)), j)
+	ctx := context.Background()
+	order := testOrder(line("1", "CN", "1"))
+
+	res, err := s.Submit(ctx, order)
+	if err != nil || res.SuccessTotal != "1" {
+		t.Fatalf("Submit = %+v, %v", res, err)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StateImported || e.Attempts != 1 || e.Order.AccessToken != "" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	again, err := s.Submit(ctx, order)
+	if !errors.Is(err, ErrAlreadyImported) || again.SuccessTotal != "1" {
+		t.Errorf("second Submit = %+v, %v; want the first response and ErrAlreadyImported", again, err)
+	}
+	if n := srv.Requests(client.EndpointImportOrder); n != 1 {
+		t.Errorf("importOrder called %d times, want 1", n)
+	}
+}
+
+func TestSubmitFailedIsResent(t *testing.T) {
+	srv := submitServer(t)
+	j := NewMemoryJournal()
+	s := NewSubmitter(srv.ManagedClient(), j)
+	ctx := context.Background()
+	order := testOrder(line("1", "CN", "10"))
+
+	res, err := s.Submit(ctx, order)
+	if err != nil || res.SuccessTotal != "0" {
+		t.Fatalf("Submit = %+v, %v", res, err)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StateFailed {
+		t.Fatalf("entry = %+v", e)
+	}
+
+	srv.SetStock("1", "CN", 100, 20)
+	if res, err := s.Submit(ctx, order); err != nil || res.SuccessTotal != "1" {
+		t.Fatalf("resubmit = %+v, %v", res, err)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StateImported || e.Attempts != 2 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestSubmitLostResponse(t *testing.T) {
+	srv := submitServer(t)
+	j := NewMemoryJournal()
+	drop := &http.Client{Transport: dropImports{next: srv.Client().Transport}}
+	s := NewSubmitter(srv.ManagedClient(client.WithHTTPClient(drop), client.WithRetryPolicy(eagerRetry)), j)
+	order := testOrder(line("1", "CN", "1"))
+
+	_, err := s.Submit(context.Background(), order)
+	if !errors.Is(err, ErrAlreadyImported) {
+		t.Fatalf("Submit = %v, want ErrAlreadyImported", err)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StateImported {
+		t.Errorf("entry = %+v", e)
+	}
+	if n := srv.Requests(client.EndpointImportOrder); n != 1 {
+		t.Errorf("importOrder called %d times, want 1", n)
+	}
+	if ids := srv.OrderIDs(order.SaleRecordID); len(ids) != 1 {
+		t.Errorf("orders = %v, want one", ids)
+	}
+}
+
+func TestSubmitUnavailable(t *testing.T) {
+	srv := submitServer(t)
+	srv.InjectFault(client.EndpointImportOrder, banggoodtest.Fault{Code: client.CodeSystemBusy, Message: "busy", HTTPStatus: http.StatusServiceUnavailable})
+	j := NewMemoryJournal()
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSubmitter(srv.ManagedClient(client.WithRetryPolicy(eagerRetry)), j)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	order := testOrder(line("1", "CN", "1"))
+
+	if _, err := s.Submit(ctx, order); !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("Submit = %v, want the 503", err)
+	}
+	if n := srv.Requests(client.EndpointImportOrder); n != 1 {
+		t.Errorf("importOrder called %d times, want 1", n)
+	}
+	if n := srv.Requests(client.EndpointGetOrderInfo); n != 1 {
+		t.Errorf("getOrderInfo called %d times, want 1", n)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StatePending || e.Error == "" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// The outcome is unknown, so the sale record is held until it is stale.
+	if _, err := s.Submit(ctx, order); !errors.Is(err, ErrSubmitInProgress) {
+		t.Errorf("Submit while pending = %v, want ErrSubmitInProgress", err)
+	}
+	if n := srv.Requests(client.EndpointImportOrder); n != 1 {
+		t.Errorf("importOrder called %d times, want 1", n)
+	}
+
+	now = now.Add(DefaultStaleAfter)
+	if res, err := s.Submit(ctx, order); err != nil || res.SuccessTotal != "1" {
+		t.Fatalf("Submit once stale = %+v, %v", res, err)
+	}
+	if e := entryOf(t, j, order.SaleRecordID); e.State != StateImported || e.Attempts != 2 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestSubmitInProgress(t *testing.T) {
+	srv := submitServer(t)
+	j := NewMemoryJournal()
+	s := NewSubmitter(srv.ManagedClient(), j)
+	ctx := context.Background()
+	order := testOrder(line("1", "CN", "1"))
+
+	now := time.Now()
+	if err := j.Create(ctx, Entry{SaleRecordID: order.SaleRecordID, State: StatePending, Order: order, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Submit(ctx, order); !errors.Is(err, ErrSubmitInProgress) {
+		t.Errorf("Submit = %v, want ErrSubmitInProgress", err)
+	}
+	if n := srv.Requests(client.EndpointImportOrder) + srv.Requests(client.EndpointGetOrderInfo); n != 0 {
+		t.Errorf("%d requests for an order in flight", n)
+	}
+
+	// Another Submit claims the failed entry between our read and update.
+	e := entryOf(t, j, order.SaleRecordID)
+	e.State = StateFailed
+	if err := j.Update(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	racing := racingJournal{Journal: j}
+	s.Journal = racing
+	if _, err := s.Submit(ctx, order); !errors.Is(err, ErrSubmitInProgress) {
+		t.Errorf("Submit losing the claim = %v, want ErrSubmitInProgress", err)
+	}
+	if n := srv.Requests(client.EndpointImportOrder); n != 0 {
+		t.Errorf("importOrder called %d times, want 0", n)
+	}
+}
+
+// racingJournal bumps an entry's version right after it is read, as a
+// concurrent writer would.
+type racingJournal struct {
+	Journal
+}
+
+func (r racingJournal) Get(ctx context.Context, saleRecordID string) (Entry, bool, error) {
+	e, ok, err := r.Journal.Get(ctx, saleRecordID)
+	if ok && err == nil {
+		err = r.Journal.Update(ctx, e)
+	}
+	return e, ok, err
+}
+
+func TestReconcile(t *testing.T) {
+	srv := submitServer(t)
+	j := NewMemoryJournal()
+	s := NewSubmitter(srv.ManagedClient(), j)
+	ctx := context.Background()
+
+	landed := testOrder(line("1", "CN", "1"))
+	if _, err := srv.ManagedClient().ImportOrder(ctx, landed); err != nil {
+		t.Fatal(err)
+	}
+	lost := testOrder(line("1", "CN", "1"))
+	lost.SaleRecordID = "SR-2"
+	for _, order := range []client.ImportOrderRequest{landed, lost} {
+		if err := j.Create(ctx, Entry{SaleRecordID: order.SaleRecordID, State: StatePending, Order: order}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	imported, err := s.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 1 || imported[0].SaleRecordID != landed.SaleRecordID {
+		t.Errorf("imported = %+v", imported)
+	}
+	if e := entryOf(t, j, lost.SaleRecordID); e.State != StatePending {
+		t.Errorf("lost entry = %+v", e)
+	}
+}
@@ -0,0 +1,260 @@
+// Package pricing turns Banggood costs into retail prices.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// Rate is a proportion in basis points: 100 is 1%.
+type Rate int64
+
+const (
+	BasisPoint Rate = 1
+	Percent    Rate = 100
+)
+
+func (r Rate) String() string {
+	sign := ""
+	if r < 0 {
+		sign, r = "-", -r
+	}
+	return fmt.Sprintf("%s%d.%02d%%", sign, r/100, r%100)
+}
+
+func (r Rate) of(m client.Money) client.Money {
+	return m.MulRatio(int64(r), 10000)
+}
+
+var (
+	ErrInvalidRules = errors.New("pricing: invalid rules")
+	ErrNoShipMethod = errors.New("pricing: ship method not available")
+)
+
+// Tier marks up landed costs below UpTo by Markup. A zero UpTo has no upper
+// bound.
+type Tier struct {
+	UpTo   client.Money
+	Markup Rate
+}
+
+// Rules configure how a landed cost becomes a retail price. Fees without a
+// currency are taken to be in the currency of the quote; the others must
+// all be in that currency.
+type Rules struct {
+	// Tiers are matched in order of UpTo against the landed cost.
+	Tiers []Tier
+	// FixedFee is added once per quote, e.g. for handling or packaging.
+	FixedFee client.Money
+	// PaymentRate and PaymentFixed describe the payment processor's cut of
+	// the retail price.
+	PaymentRate  Rate
+	PaymentFixed client.Money
+	// MinMargin is the lowest acceptable profit as a share of the price.
+	MinMargin Rate
+	// Round99 rounds the price up to the next amount ending in .99.
+	Round99 bool
+}
+
+func (r Rules) Validate() error {
+	if r.PaymentRate < 0 || r.MinMargin < 0 {
+		return fmt.Errorf("%w: rates must not be negative", ErrInvalidRules)
+	}
+	if r.PaymentRate+r.MinMargin >= 100*Percent {
+		return fmt.Errorf("%w: payment rate %s plus minimum margin %s must stay below 100%%", ErrInvalidRules, r.PaymentRate, r.MinMargin)
+	}
+	for i, t := range r.Tiers {
+		if t.Markup < 0 {
+			return fmt.Errorf("%w: tier %d has a negative markup", ErrInvalidRules, i)
+		}
+	}
+	if _, err := r.currency(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	return nil
+}
+
+// currency returns the currency of the amounts in r, or "" if none has one.
+func (r Rules) currency() (string, error) {
+	amounts := []client.Money{r.FixedFee, r.PaymentFixed}
+	for _, t := range r.Tiers {
+		amounts = append(amounts, t.UpTo)
+	}
+	return client.CheckCurrency(amounts...)
+}
+
+// markup returns the markup rate of the first tier covering landed.
+func (r Rules) markup(landed client.Money) Rate {
+	tiers := append([]Tier(nil), r.Tiers...)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		switch {
+		case tiers[i].UpTo.IsZero():
+			return false
+		case tiers[j].UpTo.IsZero():
+			return true
+		}
+		return tiers[i].UpTo.MustCmp(tiers[j].UpTo) < 0
+	})
+	for _, t := range tiers {
+		if t.UpTo.IsZero() || landed.MustCmp(t.UpTo) < 0 {
+			return t.Markup
+		}
+	}
+	return 0
+}
+
+// Breakdown is a retail price and the costs it covers. Amounts are totals
+// for Quantity units.
+type Breakdown struct {
+	Quantity    int          `json:"quantity"`
+	UnitCost    client.Money `json:"unit_cost"`
+	ProductCost client.Money `json:"product_cost"`
+	Shipping    client.Money `json:"shipping"`
+	LandedCost  client.Money `json:"landed_cost"`
+	MarkupRate  Rate         `json:"markup_rate"`
+	Markup      client.Money `json:"markup"`
+	FixedFee    client.Money `json:"fixed_fee"`
+	PaymentFee  client.Money `json:"payment_fee"`
+	Price       client.Money `json:"price"`
+	Profit      client.Money `json:"profit"`
+	Margin      Rate         `json:"margin"`
+	// MinMarginApplied reports that the markup alone fell short of
+	// Rules.MinMargin and the price was raised to meet it.
+	MinMarginApplied bool   `json:"min_margin_applied"`
+	ShipMethodCode   string `json:"shipmethod_code,omitempty"`
+	ShipMethodName   string `json:"shipmethod_name,omitempty"`
+}
+
+// Price computes the retail price of quantity units costing unitCost each,
+// shipped for shipping in total.
+func (r Rules) Price(unitCost, shipping client.Money, quantity int) (Breakdown, error) {
+	if err := r.Validate(); err != nil {
+		return Breakdown{}, err
+	}
+	if quantity < 1 {
+		return Breakdown{}, fmt.Errorf("pricing: quantity %d must be positive", quantity)
+	}
+	cur, err := client.CheckCurrency(unitCost, shipping)
+	if err != nil {
+		return Breakdown{}, fmt.Errorf("pricing: unit cost and shipping: %w", err)
+	}
+	rulesCur, _ := r.currency()
+	switch {
+	case cur == "":
+		cur = rulesCur
+	case rulesCur != "" && rulesCur != cur:
+		return Breakdown{}, fmt.Errorf("%w: rules are in %s but the quote is in %s", ErrInvalidRules, rulesCur, cur)
+	}
+	b := Breakdown{
+		Quantity:    quantity,
+		UnitCost:    unitCost.In(cur),
+		ProductCost: unitCost.Mul(int64(quantity)).In(cur),
+		Shipping:    shipping.In(cur),
+		FixedFee:    r.FixedFee.MustAdd(client.Money{Currency: cur}),
+	}
+	b.LandedCost = b.ProductCost.MustAdd(b.Shipping)
+	b.MarkupRate = r.markup(b.LandedCost)
+	b.Markup = b.MarkupRate.of(b.LandedCost).RoundCurrency()
+	paymentFixed := r.PaymentFixed.MustAdd(client.Money{Currency: cur})
+
+	// The payment fee is a share of the price itself, so
+	// price = (costs + fixed payment fee) / (1 - payment rate).
+	costs := b.LandedCost.MustAdd(b.FixedFee)
+	price := costs.MustAdd(b.Markup).MustAdd(paymentFixed).MulRatio(10000, int64(100*Percent-r.PaymentRate))
+	floor := costs.MustAdd(paymentFixed).MulRatio(10000, int64(100*Percent-r.PaymentRate-r.MinMargin))
+	if price.MustCmp(floor) < 0 {
+		price, b.MinMarginApplied = floor, true
+	}
+
+	price = roundUp(price)
+	if r.Round99 {
+		price = round99(price)
+	}
+	b.Price = price
+	b.PaymentFee = r.PaymentRate.of(price).MustAdd(paymentFixed).RoundCurrency()
+	b.Profit = price.MustSub(costs).MustSub(b.PaymentFee)
+	if price.Sign() > 0 {
+		b.Margin = Rate(math.Round(b.Profit.Float64() / price.Float64() * 10000))
+	}
+	return b, nil
+}
+
+// roundUp rounds m up to the minor unit of its currency, so rounding never
+// eats into the margin.
+func roundUp(m client.Money) client.Money {
+	r := m.RoundCurrency()
+	if r.MustCmp(m) < 0 {
+		r = r.MustAdd(client.NewMoney(1, m.Decimals(), m.Currency))
+	}
+	return r
+}
+
+// round99 rounds m up to the next amount ending in .99, e.g. 12.50 to 12.99
+// and 13.00 to 13.99.
+func round99(m client.Money) client.Money {
+	cent := client.NewMoney(1, 2, m.Currency)
+	whole := m.MustAdd(cent).Round(0)
+	if whole.MustCmp(m.MustAdd(cent)) < 0 {
+		whole = whole.MustAdd(client.NewMoney(1, 0, m.Currency))
+	}
+	return whole.MustSub(cent)
+}
+
+// Request identifies what to price.
+type Request struct {
+	ProductID string
+	PoaID     string
+	Warehouse string
+	Country   string
+	Quantity  int
+	// Currency defaults to the client's currency.
+	Currency string
+	// ShipMethodCode selects the ship method; the cheapest is used when
+	// it is empty.
+	ShipMethodCode string
+}
+
+// Engine prices products with live costs from the Banggood API.
+type Engine struct {
+	Client client.ManagedClient
+	Rules  Rules
+}
+
+func NewEngine(c client.ManagedClient, rules Rules) *Engine {
+	return &Engine{Client: c, Rules: rules}
+}
+
+// Quote fetches the unit cost and shipping fee of req and prices them with
+// e.Rules.
+func (e *Engine) Quote(ctx context.Context, req Request) (Breakdown, error) {
+	if req.Quantity < 1 {
+		return Breakdown{}, fmt.Errorf("pricing: quantity %d must be positive", req.Quantity)
+	}
+	price, err := e.Client.GetProductPrice(ctx, req.ProductID, req.PoaID, req.Warehouse, req.Currency)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	shipments, err := e.Client.GetShipments(ctx, req.ProductID, req.Warehouse, req.Country, req.PoaID, req.Currency, req.Quantity)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	shipment, ok := shipments.ShipMethods.Cheapest()
+	if req.ShipMethodCode != "" {
+		shipment, ok = shipments.ShipMethods.Lookup(req.ShipMethodCode)
+	}
+	if !ok {
+		return Breakdown{}, fmt.Errorf("%w: no ship method %q from %s to %s", ErrNoShipMethod, req.ShipMethodCode, req.Warehouse, req.Country)
+	}
+	b, err := e.Rules.Price(price.ProductPrice, shipment.Shipfee, req.Quantity)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	b.ShipMethodCode = shipment.ShipMethodCode
+	b.ShipMethodName = shipment.ShipMethodName
+	return b, nil
+}
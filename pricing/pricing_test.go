@@ -0,0 +1,176 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+func usd(s string) client.Money {
+	m, err := client.ParseMoney(s, "USD")
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func testRules() Rules {
+	return Rules{
+		Tiers: []Tier{
+			{Markup: 20 * Percent},
+			{UpTo: usd("50"), Markup: 30 * Percent},
+		},
+		FixedFee:     client.NewMoney(1, 0, ""),
+		PaymentRate:  3 * Percent,
+		PaymentFixed: client.NewMoney(30, 2, ""),
+	}
+}
+
+func TestRulesPrice(t *testing.T) {
+	b, err := testRules().Price(usd("10"), usd("5"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]client.Money{
+		"ProductCost": b.ProductCost,
+		"LandedCost":  b.LandedCost,
+		"Markup":      b.Markup,
+		"FixedFee":    b.FixedFee,
+		"Price":       b.Price,
+		"PaymentFee":  b.PaymentFee,
+		"Profit":      b.Profit,
+	}
+	for name, amount := range map[string]string{
+		"ProductCost": "USD 20.00",
+		"LandedCost":  "USD 25.00",
+		"Markup":      "USD 7.50",
+		"FixedFee":    "USD 1.00",
+		"Price":       "USD 34.85",
+		"PaymentFee":  "USD 1.35",
+		"Profit":      "USD 7.50",
+	} {
+		if got[name].String() != amount {
+			t.Errorf("%s = %s, want %s", name, got[name], amount)
+		}
+	}
+	if b.MarkupRate != 30*Percent || b.Margin != 2152 || b.MinMarginApplied {
+		t.Errorf("MarkupRate %s, Margin %s, MinMarginApplied %v", b.MarkupRate, b.Margin, b.MinMarginApplied)
+	}
+}
+
+func TestRulesPriceTiers(t *testing.T) {
+	b, err := testRules().Price(usd("60"), usd("0"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.MarkupRate != 20*Percent {
+		t.Errorf("MarkupRate = %s, want 20%%", b.MarkupRate)
+	}
+}
+
+func TestRulesPriceMinMargin(t *testing.T) {
+	rules := testRules()
+	rules.MinMargin = 30 * Percent
+	b, err := rules.Price(usd("10"), usd("5"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.MinMarginApplied || b.Price.String() != "USD 39.26" {
+		t.Errorf("Price = %s, MinMarginApplied = %v", b.Price, b.MinMarginApplied)
+	}
+	if b.Margin < rules.MinMargin {
+		t.Errorf("Margin = %s, below %s", b.Margin, rules.MinMargin)
+	}
+}
+
+func TestRound99(t *testing.T) {
+	for in, want := range map[string]string{
+		"12.50": "12.99",
+		"13.00": "13.99",
+		"12.99": "12.99",
+		"0.01":  "0.99",
+	} {
+		if got := round99(usd(in)).Amount(); got != want {
+			t.Errorf("round99(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+	}{
+		{"negative rate", Rules{PaymentRate: -1}},
+		{"rates reach 100%", Rules{PaymentRate: 60 * Percent, MinMargin: 40 * Percent}},
+		{"negative markup", Rules{Tiers: []Tier{{Markup: -Percent}}}},
+		{"mixed currencies", Rules{FixedFee: usd("1"), Tiers: []Tier{{UpTo: client.NewMoney(50, 0, "EUR")}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.rules.Validate(); !errors.Is(err, ErrInvalidRules) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidRules", tt.name, err)
+		}
+	}
+	if err := testRules().Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestRulesPriceCurrencyMismatch(t *testing.T) {
+	rules := testRules()
+	rules.FixedFee = usd("1")
+	eur := client.NewMoney(10, 0, "EUR")
+	if _, err := rules.Price(eur, eur, 1); !errors.Is(err, ErrInvalidRules) {
+		t.Errorf("USD rules on EUR quote = %v, want ErrInvalidRules", err)
+	}
+	if _, err := rules.Price(usd("10"), eur, 1); !errors.Is(err, client.ErrCurrencyMismatch) {
+		t.Errorf("USD cost with EUR shipping = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := (Rules{Tiers: []Tier{{Markup: 20 * Percent}}}).Price(eur, eur, 1); err != nil {
+		t.Errorf("currency-less rules on EUR quote = %v", err)
+	}
+}
+
+func TestEngineQuote(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(1, 1, 1)
+	srv.Seed(catalog)
+	p := catalog.Products[0]
+	ctx := context.Background()
+	c := srv.ManagedClient()
+
+	req := Request{
+		ProductID: p.ProductID,
+		PoaID:     strconv.Itoa(p.Stocks[0].StocksList[0].PoaID),
+		Warehouse: p.Stocks[0].Warehouse,
+		Country:   "Germany",
+		Quantity:  2,
+		Currency:  "USD",
+	}
+	e := NewEngine(c, testRules())
+	b, err := e.Quote(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shipments, err := c.GetShipments(ctx, req.ProductID, req.Warehouse, req.Country, req.PoaID, req.Currency, req.Quantity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cheapest, _ := shipments.ShipMethods.Cheapest()
+	if b.ShipMethodCode != cheapest.ShipMethodCode || b.Shipping.MustCmp(cheapest.Shipfee) != 0 {
+		t.Errorf("quoted %s at %s, want cheapest %s at %s", b.ShipMethodCode, b.Shipping, cheapest.ShipMethodCode, cheapest.Shipfee)
+	}
+	if b.Price.MustCmp(b.LandedCost) <= 0 {
+		t.Errorf("Price %s does not cover landed cost %s", b.Price, b.LandedCost)
+	}
+
+	req.ShipMethodCode = "no-such-method"
+	if _, err := e.Quote(ctx, req); !errors.Is(err, ErrNoShipMethod) {
+		t.Errorf("Quote = %v, want ErrNoShipMethod", err)
+	}
+}
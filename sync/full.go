@@ -0,0 +1,309 @@
+// Package sync mirrors the Banggood catalog into a Store.
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	gosync "sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultInterval    = 5 * time.Minute
+)
+
+// Summary describes what a sync changed in the store.
+type Summary struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+func (s *Summary) merge(other Summary) {
+	s.Added = append(s.Added, other.Added...)
+	s.Changed = append(s.Changed, other.Changed...)
+	s.Removed = append(s.Removed, other.Removed...)
+	s.Unchanged += other.Unchanged
+}
+
+type change int
+
+const (
+	unchanged change = iota
+	added
+	changed
+)
+
+func (s *Summary) record(productID string, c change) {
+	switch c {
+	case added:
+		s.Added = append(s.Added, productID)
+	case changed:
+		s.Changed = append(s.Changed, productID)
+	default:
+		s.Unchanged++
+	}
+}
+
+// Syncer crawls the catalog through Client and mirrors it into Store.
+type Syncer struct {
+	Client      client.ManagedClient
+	Store       Store
+	Concurrency int
+	Logger      client.Logger
+	// Interval is the delay between polls in Run.
+	Interval time.Duration
+	// OnEvent, if set, is called for every change applied by Poll.
+	OnEvent func(Event)
+
+	now func() time.Time
+}
+
+func NewSyncer(c client.ManagedClient, store Store) *Syncer {
+	return &Syncer{
+		Client:      c,
+		Store:       store,
+		Concurrency: DefaultConcurrency,
+		Interval:    DefaultInterval,
+		now:         time.Now,
+	}
+}
+
+func (s *Syncer) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+func (s *Syncer) logf(format string, v ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, v...)
+	}
+}
+
+// Full crawls every category and product, fetching product info and stock
+// for each. Progress is checkpointed after each category, so a crawl that
+// fails or is cancelled resumes where it stopped on the next call. Products
+// missing from a completed crawl are removed from the store, and incremental
+// syncs continue from the time the crawl started.
+func (s *Syncer) Full(ctx context.Context) (Summary, error) {
+	cp, err := s.Store.Checkpoint(ctx)
+	if err != nil {
+		return Summary{}, err
+	}
+	if cp == nil {
+		cp = &Checkpoint{StartedAt: s.clock()}
+	} else {
+		s.logf("banggood sync: resuming crawl started at %s, %d categories done", cp.StartedAt, len(cp.Categories))
+	}
+
+	categories, err := s.Client.GetAllCategories(ctx)
+	if err != nil {
+		return cp.Summary, err
+	}
+	if err := s.Store.PutCategories(ctx, categories); err != nil {
+		return cp.Summary, err
+	}
+
+	done := map[string]bool{}
+	for _, id := range cp.Categories {
+		done[id] = true
+	}
+	seen := map[string]bool{}
+	for _, id := range cp.Seen {
+		seen[id] = true
+	}
+
+	for _, category := range categories {
+		if done[category.CategoryID] {
+			continue
+		}
+		summary, ids, err := s.crawlCategory(ctx, category.CategoryID, seen)
+		if err != nil {
+			return cp.Summary, err
+		}
+		for _, id := range ids {
+			seen[id] = true
+		}
+		cp.Categories = append(cp.Categories, category.CategoryID)
+		cp.Seen = append(cp.Seen, ids...)
+		cp.Summary.merge(summary)
+		if err := s.Store.SaveCheckpoint(ctx, cp); err != nil {
+			return cp.Summary, err
+		}
+		s.logf("banggood sync: category %s done, %d products", category.CategoryID, len(ids))
+	}
+
+	stored, err := s.Store.ProductIDs(ctx)
+	if err != nil {
+		return cp.Summary, err
+	}
+	for _, id := range stored {
+		if seen[id] {
+			continue
+		}
+		if err := s.Store.Delete(ctx, id); err != nil {
+			return cp.Summary, err
+		}
+		cp.Summary.Removed = append(cp.Summary.Removed, id)
+	}
+	if err := s.advanceHighWater(ctx, cp.StartedAt); err != nil {
+		return cp.Summary, err
+	}
+	return cp.Summary, s.Store.SaveCheckpoint(ctx, nil)
+}
+
+// crawlCategory syncs every product of a category not already in seen. It
+// returns the IDs it synced.
+func (s *Syncer) crawlCategory(ctx context.Context, categoryID string, seen map[string]bool) (Summary, []string, error) {
+	return s.syncProducts(ctx, func(ctx context.Context) productSource {
+		return s.Client.Products(ctx, categoryID, nil, nil, nil, nil)
+	}, seen, nil)
+}
+
+type productSource interface {
+	Next() (client.Product, error)
+}
+
+// syncProducts syncs every product read from the source returned by open
+// that is not already in seen, using up to s.Concurrency workers. It returns
+// the IDs it synced. A product that fails aborts the sync unless skip is set
+// and returns true for the error; skip is called concurrently.
+func (s *Syncer) syncProducts(ctx context.Context, open func(ctx context.Context) productSource, seen map[string]bool, skip func(client.Product, error) bool) (Summary, []string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := s.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu       gosync.Mutex
+		summary  Summary
+		firstErr error
+		wg       gosync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	jobs := make(chan client.Product)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				c, err := s.syncProduct(ctx, p)
+				if err != nil {
+					if skip == nil || !skip(p, err) {
+						fail(err)
+					}
+					continue
+				}
+				mu.Lock()
+				summary.record(p.ProductID, c)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var ids []string
+	queued := map[string]bool{}
+	it := open(ctx)
+loop:
+	for {
+		p, err := it.Next()
+		if err == client.Done {
+			break
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+		if seen[p.ProductID] || queued[p.ProductID] {
+			continue
+		}
+		queued[p.ProductID] = true
+		select {
+		case jobs <- p:
+			ids = append(ids, p.ProductID)
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return Summary{}, nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return Summary{}, nil, err
+	}
+	return summary, ids, nil
+}
+
+// syncProduct fetches the full state of p and stores it if it differs from
+// what the store holds.
+func (s *Syncer) syncProduct(ctx context.Context, p client.Product) (change, error) {
+	info, err := s.Client.GetProductInfo(ctx, p.ProductID, nil)
+	if err != nil {
+		return unchanged, err
+	}
+	stock, err := s.Client.GetStock(ctx, p.ProductID)
+	if err != nil {
+		return unchanged, err
+	}
+
+	existing, ok, err := s.Store.Get(ctx, p.ProductID)
+	if err != nil {
+		return unchanged, err
+	}
+	record := Record{
+		Product: p,
+		Info:    info,
+		Stock:   stock,
+	}
+	if record.Checksum, err = checksum(record); err != nil {
+		return unchanged, err
+	}
+	if ok && existing.Checksum == record.Checksum && existing.State == 0 {
+		return unchanged, nil
+	}
+
+	record.SyncedAt = s.clock()
+	if err := s.Store.Put(ctx, record); err != nil {
+		return unchanged, err
+	}
+	if ok {
+		return changed, nil
+	}
+	return added, nil
+}
+
+// checksum fingerprints the API data of r, ignoring bookkeeping fields.
+func checksum(r Record) (string, error) {
+	b, err := json.Marshal(struct {
+		Product client.Product
+		Info    client.GetProductInfoResponse
+		Stock   client.GetStockResponse
+	}{r.Product, r.Info, r.Stock})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,147 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// logFunc adapts a function to client.Logger.
+type logFunc func(format string, v ...interface{})
+
+func (f logFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
+
+func productIDs(catalog banggoodtest.Catalog) []string {
+	var ids []string
+	for _, p := range catalog.Products {
+		ids = append(ids, p.ProductID)
+	}
+	return ids
+}
+
+func sorted(ids []string) []string {
+	ids = append([]string(nil), ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+func TestFull(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"file": func(t *testing.T) Store {
+			s, err := NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			srv := banggoodtest.NewServer()
+			defer srv.Close()
+			catalog := banggoodtest.GenerateCatalog(3, 3, 2)
+			srv.Seed(catalog)
+			s := NewSyncer(srv.ManagedClient(), newStore(t))
+			s.now = srv.Now
+			ctx := context.Background()
+
+			start := srv.Now()
+			summary, err := s.Full(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sorted(summary.Added), productIDs(catalog)) || len(summary.Changed) != 0 || len(summary.Removed) != 0 {
+				t.Errorf("first Full = %+v", summary)
+			}
+			if stored, _ := s.Store.ProductIDs(ctx); !reflect.DeepEqual(sorted(stored), productIDs(catalog)) {
+				t.Errorf("stored products = %v", stored)
+			}
+			if categories, _ := s.Store.Categories(ctx); len(categories) != len(catalog.Categories) {
+				t.Errorf("stored %d categories, want %d", len(categories), len(catalog.Categories))
+			}
+			if hw, _ := s.Store.HighWater(ctx); hw.Before(start) || hw.After(srv.Now()) {
+				t.Errorf("high-water mark = %v, want the start of the crawl %v", hw, start)
+			}
+			if cp, _ := s.Store.Checkpoint(ctx); cp != nil {
+				t.Errorf("checkpoint left after a completed crawl: %+v", cp)
+			}
+
+			changedID, removedID := catalog.Products[0].ProductID, catalog.Products[1].ProductID
+			stock := catalog.Products[0].Stocks[0]
+			srv.SetStock(changedID, stock.Warehouse, stock.StocksList[0].PoaID, 999)
+			srv.SetProductState(removedID, banggoodtest.StateDeleted)
+			summary, err = s.Full(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(summary.Changed, []string{changedID}) || !reflect.DeepEqual(summary.Removed, []string{removedID}) ||
+				len(summary.Added) != 0 || summary.Unchanged != len(catalog.Products)-2 {
+				t.Errorf("second Full = %+v", summary)
+			}
+			if _, ok, _ := s.Store.Get(ctx, removedID); ok {
+				t.Error("removed product is still stored")
+			}
+		})
+	}
+}
+
+func TestFullResumes(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	catalog := banggoodtest.GenerateCatalog(3, 3, 2)
+	srv.Seed(catalog)
+	s := NewSyncer(srv.ManagedClient(), NewMemoryStore())
+	s.now = srv.Now
+	ctx := context.Background()
+
+	// Fail the listing of the second category, after the first has been
+	// checkpointed.
+	injected := false
+	s.Logger = logFunc(func(format string, v ...interface{}) {
+		if !injected {
+			injected = true
+			srv.InjectFault(client.EndpointGetProductList, banggoodtest.Fault{Code: client.CodeRateLimited, Message: "slow down"})
+		}
+	})
+	first, err := s.Full(ctx)
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("interrupted Full = %v, want ErrRateLimited", err)
+	}
+	cp, _ := s.Store.Checkpoint(ctx)
+	if cp == nil || len(cp.Categories) != 1 || len(cp.Seen) != 2 || len(first.Added) != 2 {
+		t.Fatalf("checkpoint = %+v, summary = %+v", cp, first)
+	}
+	if hw, _ := s.Store.HighWater(ctx); !hw.IsZero() {
+		t.Errorf("interrupted crawl set the high-water mark to %v", hw)
+	}
+
+	var logged []string
+	s.Logger = logFunc(func(format string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, v...))
+	})
+	summary, err := s.Full(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sorted(summary.Added), productIDs(catalog)) {
+		t.Errorf("resumed Full added %v", summary.Added)
+	}
+	if n := srv.Requests(client.EndpointGetProductList); n != 4 {
+		t.Errorf("getProductList called %d times, want the first category listed once", n)
+	}
+	if len(logged) != 3 {
+		t.Errorf("log = %q, want the resume and two categories", logged)
+	}
+	if hw, _ := s.Store.HighWater(ctx); !hw.Equal(cp.StartedAt) {
+		t.Errorf("high-water mark = %v, want the start of the interrupted crawl %v", hw, cp.StartedAt)
+	}
+}
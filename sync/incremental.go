@@ -0,0 +1,270 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	gosync "sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// EventType classifies a change applied by Poll.
+type EventType int
+
+const (
+	EventUpdated EventType = iota + 1
+	EventOffShelf
+	EventDeleted
+	// EventFailed reports a product Banggood rejected as invalid; the store
+	// keeps its previous state.
+	EventFailed
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventUpdated:
+		return "updated"
+	case EventOffShelf:
+		return "off-shelf"
+	case EventDeleted:
+		return "deleted"
+	case EventFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+func eventType(state int) EventType {
+	switch state {
+	case client.ProductStateOffShelf:
+		return EventOffShelf
+	case client.ProductStateDeleted:
+		return EventDeleted
+	}
+	return EventUpdated
+}
+
+// Event reports one product change applied to the store.
+type Event struct {
+	Type       EventType `json:"type"`
+	ProductID  string    `json:"product_id"`
+	ModifyDate time.Time `json:"modify_date"`
+	// Error is why an EventFailed product could not be synced.
+	Error string `json:"error,omitempty"`
+}
+
+// overlap is added to every poll window so updates stamped on a minute
+// boundary are not lost to rounding or clock skew.
+const overlap = time.Minute
+
+// Run polls for updates every s.Interval until ctx is cancelled. Failed
+// polls are logged and retried on the next tick; the high-water mark only
+// moves forward after a successful poll, so no updates are skipped.
+func (s *Syncer) Run(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logf("banggood sync: poll failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll applies every product update since the stored high-water mark:
+// updated products are refetched, off-shelf products are flagged and deleted
+// products are removed. It returns an event for each change to the store.
+// Without a high-water mark, the window is s.Interval.
+// Products Banggood rejects as invalid do not hold back the high-water mark:
+// they are reported as EventFailed, keep their stored state and are left for
+// the next full crawl. Any other error, such as rejected credentials, rate
+// limiting, a server error or an unknown code, aborts the poll without
+// moving the high-water mark, so the next poll covers the same window.
+func (s *Syncer) Poll(ctx context.Context) ([]Event, error) {
+	now := s.clock()
+	since, err := s.Store.HighWater(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if since.IsZero() {
+		interval := s.Interval
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+		since = now.Add(-interval)
+	}
+	window := now.Sub(since) + overlap
+	minutes := int((window + time.Minute - 1) / time.Minute)
+
+	updates, err := s.Client.GetAllProductUpdates(ctx, minutes)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]client.ProductUpdate{}
+	var order []string
+	for _, u := range updates {
+		prev, ok := latest[u.ProductID]
+		if !ok {
+			order = append(order, u.ProductID)
+		}
+		if !ok || !u.ModifyDate.Before(prev.ModifyDate.Time) {
+			latest[u.ProductID] = u
+		}
+	}
+
+	var (
+		events  []Event
+		refetch []client.Product
+	)
+	for _, id := range order {
+		u := latest[id]
+		switch eventType(u.State) {
+		case EventDeleted:
+			_, ok, err := s.Store.Get(ctx, id)
+			if err != nil {
+				return events, err
+			}
+			if !ok {
+				continue
+			}
+			if err := s.Store.Delete(ctx, id); err != nil {
+				return events, err
+			}
+			events = s.emit(events, EventDeleted, u)
+		case EventOffShelf:
+			r, ok, err := s.Store.Get(ctx, id)
+			if err != nil {
+				return events, err
+			}
+			if !ok || r.State == client.ProductStateOffShelf {
+				continue
+			}
+			r.State = client.ProductStateOffShelf
+			r.SyncedAt = now
+			if err := s.Store.Put(ctx, r); err != nil {
+				return events, err
+			}
+			events = s.emit(events, EventOffShelf, u)
+		default:
+			p, err := s.knownProduct(ctx, u)
+			if err != nil {
+				return events, err
+			}
+			refetch = append(refetch, p)
+		}
+	}
+
+	var (
+		mu     gosync.Mutex
+		failed = map[string]error{}
+	)
+	summary, _, err := s.syncProducts(ctx, func(ctx context.Context) productSource {
+		return &sliceSource{products: refetch}
+	}, nil, func(p client.Product, err error) bool {
+		if !productError(err) {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		failed[p.ProductID] = err
+		return true
+	})
+	if err != nil {
+		return events, err
+	}
+	changed := map[string]bool{}
+	for _, id := range append(summary.Added, summary.Changed...) {
+		changed[id] = true
+	}
+	for _, p := range refetch {
+		u := latest[p.ProductID]
+		err, ok := failed[p.ProductID]
+		switch {
+		case ok:
+			s.logf("banggood sync: product %s failed: %v", p.ProductID, err)
+			e := Event{Type: EventFailed, ProductID: u.ProductID, ModifyDate: u.ModifyDate.Time, Error: err.Error()}
+			events = s.emitEvent(events, e)
+		case changed[p.ProductID]:
+			events = s.emit(events, EventUpdated, u)
+		}
+	}
+
+	return events, s.Store.SaveHighWater(ctx, now)
+}
+
+func (s *Syncer) emit(events []Event, t EventType, u client.ProductUpdate) []Event {
+	return s.emitEvent(events, Event{Type: t, ProductID: u.ProductID, ModifyDate: u.ModifyDate.Time})
+}
+
+func (s *Syncer) emitEvent(events []Event, e Event) []Event {
+	if s.OnEvent != nil {
+		s.OnEvent(e)
+	}
+	return append(events, e)
+}
+
+// productError reports whether err is Banggood rejecting one product as
+// invalid. Server errors, unknown codes and missing parameters could hit
+// every product, so they do not count.
+func productError(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == client.CodeInvalidParameter && apiErr.HTTPStatus < http.StatusInternalServerError
+}
+
+// knownProduct returns the stored listing of u's product with its modify
+// date bumped, or a bare listing for products the store has not seen.
+func (s *Syncer) knownProduct(ctx context.Context, u client.ProductUpdate) (client.Product, error) {
+	r, ok, err := s.Store.Get(ctx, u.ProductID)
+	if err != nil {
+		return client.Product{}, err
+	}
+	p := client.Product{ProductID: u.ProductID}
+	if ok {
+		p = r.Product
+	}
+	p.ModifyDate = u.ModifyDate
+	return p, nil
+}
+
+// advanceHighWater moves the stored high-water mark to t unless it is
+// already later.
+func (s *Syncer) advanceHighWater(ctx context.Context, t time.Time) error {
+	current, err := s.Store.HighWater(ctx)
+	if err != nil {
+		return err
+	}
+	if current.After(t) {
+		return nil
+	}
+	return s.Store.SaveHighWater(ctx, t)
+}
+
+type sliceSource struct {
+	products []client.Product
+}
+
+func (s *sliceSource) Next() (client.Product, error) {
+	if len(s.products) == 0 {
+		return client.Product{}, client.Done
+	}
+	p := s.products[0]
+	s.products = s.products[1:]
+	return p, nil
+}
@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vasjaj/banggood/banggoodtest"
+	"github.com/vasjaj/banggood/client"
+)
+
+// syncedServer returns a fake API and a syncer whose store holds a full
+// crawl of it. The syncer shares the server's clock.
+func syncedServer(t *testing.T) (*banggoodtest.Server, *Syncer, banggoodtest.Catalog) {
+	t.Helper()
+	srv := banggoodtest.NewServer()
+	t.Cleanup(srv.Close)
+	catalog := banggoodtest.GenerateCatalog(7, 2, 3)
+	srv.Seed(catalog)
+	s := NewSyncer(srv.ManagedClient(), NewMemoryStore())
+	s.Concurrency = 1
+	s.now = srv.Now
+	if _, err := s.Full(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv.Advance(time.Minute)
+	return srv, s, catalog
+}
+
+func eventsByProduct(events []Event) map[string]Event {
+	m := map[string]Event{}
+	for _, e := range events {
+		m[e.ProductID] = e
+	}
+	return m
+}
+
+func TestPoll(t *testing.T) {
+	srv, s, catalog := syncedServer(t)
+	ctx := context.Background()
+	updated, offShelf, deleted := catalog.Products[0], catalog.Products[1], catalog.Products[2]
+	srv.SetStock(updated.ProductID, updated.Stocks[0].Warehouse, updated.Stocks[0].StocksList[0].PoaID, 999)
+	srv.SetProductState(offShelf.ProductID, banggoodtest.StateOffShelf)
+	srv.SetProductState(deleted.ProductID, banggoodtest.StateDeleted)
+
+	var seen []Event
+	s.OnEvent = func(e Event) { seen = append(seen, e) }
+	start := srv.Now()
+	events, err := s.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byProduct := eventsByProduct(events)
+	if len(events) != 3 || len(seen) != 3 ||
+		byProduct[updated.ProductID].Type != EventUpdated ||
+		byProduct[offShelf.ProductID].Type != EventOffShelf ||
+		byProduct[deleted.ProductID].Type != EventDeleted {
+		t.Fatalf("events = %+v", events)
+	}
+
+	if r, ok, _ := s.Store.Get(ctx, updated.ProductID); !ok || r.Stock.Stocks[0].StocksList[0].Stock != "999" {
+		t.Errorf("updated record = %+v", r)
+	}
+	if r, _, _ := s.Store.Get(ctx, offShelf.ProductID); r.State != client.ProductStateOffShelf {
+		t.Errorf("off-shelf record state = %d", r.State)
+	}
+	if _, ok, _ := s.Store.Get(ctx, deleted.ProductID); ok {
+		t.Error("deleted product is still stored")
+	}
+	if hw, _ := s.Store.HighWater(ctx); hw.Before(start) {
+		t.Errorf("high-water mark = %v, want at least %v", hw, start)
+	}
+
+	// Nothing changed since, so a second poll reports nothing new.
+	if events, err := s.Poll(ctx); err != nil || len(events) != 0 {
+		t.Errorf("second Poll = %+v, %v", events, err)
+	}
+}
+
+func TestPollProductFailures(t *testing.T) {
+	srv, s, catalog := syncedServer(t)
+	ctx := context.Background()
+	invalid, fine := catalog.Products[0], catalog.Products[1]
+	for _, p := range []banggoodtest.Product{invalid, fine} {
+		srv.SetStock(p.ProductID, p.Stocks[0].Warehouse, p.Stocks[0].StocksList[0].PoaID, 999)
+	}
+	// Products are refetched one at a time in ID order.
+	srv.InjectFault(client.EndpointGetProductInfo, banggoodtest.Fault{Code: client.CodeInvalidParameter, Message: "product_id is invalid"})
+
+	before, _ := s.Store.HighWater(ctx)
+	events, err := s.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byProduct := eventsByProduct(events)
+	if e := byProduct[invalid.ProductID]; e.Type != EventFailed || e.Error == "" {
+		t.Errorf("invalid product event = %+v", e)
+	}
+	if e := byProduct[fine.ProductID]; e.Type != EventUpdated {
+		t.Errorf("healthy product event = %+v", e)
+	}
+	if r, ok, _ := s.Store.Get(ctx, invalid.ProductID); !ok || r.Stock.Stocks[0].StocksList[0].Stock == "999" {
+		t.Errorf("invalid product record = %+v, %v; want its previous state", r, ok)
+	}
+	if after, _ := s.Store.HighWater(ctx); !after.After(before) {
+		t.Errorf("high-water mark stayed at %v", after)
+	}
+}
+
+func TestPollAbortsOnSharedFailures(t *testing.T) {
+	for name, fault := range map[string]banggoodtest.Fault{
+		"rate limited":        {Code: client.CodeRateLimited, Message: "slow down"},
+		"system busy":         {Code: client.CodeSystemBusy, Message: "system busy"},
+		"invalid credentials": {Code: client.CodeInvalidAppSecret, Message: "app_secret is invalid"},
+		"missing parameter":   {Code: client.CodeMissingParameter, Message: "product_id is required"},
+		"unknown code":        {Code: 19999, Message: "unknown error"},
+		"server error":        {Code: client.CodeInvalidParameter, HTTPStatus: http.StatusBadGateway},
+	} {
+		srv, s, catalog := syncedServer(t)
+		ctx := context.Background()
+		p := catalog.Products[0]
+		srv.SetStock(p.ProductID, p.Stocks[0].Warehouse, p.Stocks[0].StocksList[0].PoaID, 999)
+		srv.InjectFault(client.EndpointGetProductInfo, fault)
+
+		before, _ := s.Store.HighWater(ctx)
+		if _, err := s.Poll(ctx); err == nil {
+			t.Errorf("%s: Poll succeeded", name)
+		}
+		if after, _ := s.Store.HighWater(ctx); !after.Equal(before) {
+			t.Errorf("%s: high-water mark moved to %v", name, after)
+		}
+		if _, ok, _ := s.Store.Get(ctx, p.ProductID); !ok {
+			t.Errorf("%s: product was removed", name)
+		}
+
+		// The next poll covers the same window.
+		events, err := s.Poll(ctx)
+		if err != nil || eventsByProduct(events)[p.ProductID].Type != EventUpdated {
+			t.Errorf("%s: retried Poll = %+v, %v", name, events, err)
+		}
+	}
+}
+
+func TestPollWithoutHighWater(t *testing.T) {
+	srv := banggoodtest.NewServer()
+	defer srv.Close()
+	srv.Seed(banggoodtest.GenerateCatalog(7, 1, 2))
+	s := NewSyncer(srv.ManagedClient(), NewMemoryStore())
+	s.now = srv.Now
+
+	// The generated products were last modified long before the first
+	// poll window.
+	events, err := s.Poll(context.Background())
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Poll = %+v, %v", events, err)
+	}
+	if hw, _ := s.Store.HighWater(context.Background()); hw.IsZero() {
+		t.Error("Poll did not save a high-water mark")
+	}
+}
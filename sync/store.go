@@ -0,0 +1,306 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/vasjaj/banggood/client"
+)
+
+// Record is the mirrored state of one product.
+type Record struct {
+	Product client.Product                `json:"product"`
+	Info    client.GetProductInfoResponse `json:"info"`
+	Stock   client.GetStockResponse       `json:"stock"`
+	// State is client.ProductStateOffShelf while the product is off the
+	// shelf, and 0 otherwise.
+	State    int       `json:"state,omitempty"`
+	Checksum string    `json:"checksum"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Checkpoint records the progress of an unfinished full crawl.
+type Checkpoint struct {
+	StartedAt  time.Time `json:"started_at"`
+	Categories []string  `json:"categories"`
+	Seen       []string  `json:"seen"`
+	Summary    Summary   `json:"summary"`
+}
+
+// Store persists the mirrored catalog. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	PutCategories(ctx context.Context, categories []client.Category) error
+	Categories(ctx context.Context) ([]client.Category, error)
+	Get(ctx context.Context, productID string) (Record, bool, error)
+	Put(ctx context.Context, r Record) error
+	Delete(ctx context.Context, productID string) error
+	ProductIDs(ctx context.Context) ([]string, error)
+	// Checkpoint returns nil when no crawl is in progress.
+	Checkpoint(ctx context.Context) (*Checkpoint, error)
+	// SaveCheckpoint stores cp, or clears it when cp is nil.
+	SaveCheckpoint(ctx context.Context, cp *Checkpoint) error
+	// HighWater returns the time up to which incremental updates have been
+	// applied, or the zero time if none have.
+	HighWater(ctx context.Context) (time.Time, error)
+	SaveHighWater(ctx context.Context, t time.Time) error
+}
+
+type MemoryStore struct {
+	mu         gosync.RWMutex
+	categories []client.Category
+	records    map[string]Record
+	checkpoint *Checkpoint
+	highWater  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]Record{},
+	}
+}
+
+func (s *MemoryStore) PutCategories(ctx context.Context, categories []client.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = append([]client.Category(nil), categories...)
+	return nil
+}
+
+func (s *MemoryStore) Categories(ctx context.Context) ([]client.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]client.Category(nil), s.categories...), nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, productID string) (Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[productID]
+	return r, ok, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[r.Product.ProductID] = r
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, productID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, productID)
+	return nil
+}
+
+func (s *MemoryStore) ProductIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *MemoryStore) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.checkpoint == nil {
+		return nil, nil
+	}
+	cp := *s.checkpoint
+	return &cp, nil
+}
+
+func (s *MemoryStore) SaveCheckpoint(ctx context.Context, cp *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cp == nil {
+		s.checkpoint = nil
+		return nil
+	}
+	copied := *cp
+	s.checkpoint = &copied
+	return nil
+}
+
+func (s *MemoryStore) HighWater(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.highWater, nil
+}
+
+func (s *MemoryStore) SaveHighWater(ctx context.Context, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.highWater = t
+	return nil
+}
+
+const (
+	highWaterFile  = "highwater.json"
+	categoriesFile = "categories.json"
+	checkpointFile = "checkpoint.json"
+	productsDir    = "products"
+	recordExt      = ".json"
+)
+
+// FileStore keeps one JSON file per product under Dir, next to the
+// category list and the crawl checkpoint. Writes are atomic.
+type FileStore struct {
+	Dir string
+
+	mu gosync.RWMutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, productsDir), 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) productPath(productID string) string {
+	return filepath.Join(s.Dir, productsDir, filepath.Base(productID)+recordExt)
+}
+
+// writeJSON writes v to path through a temporary file so readers never see
+// a partial document.
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readJSON decodes path into v and reports false if the file does not exist.
+func readJSON(path string, v interface{}) (bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+func (s *FileStore) PutCategories(ctx context.Context, categories []client.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(filepath.Join(s.Dir, categoriesFile), categories)
+}
+
+func (s *FileStore) Categories(ctx context.Context) ([]client.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var categories []client.Category
+	_, err := readJSON(filepath.Join(s.Dir, categoriesFile), &categories)
+	return categories, err
+}
+
+func (s *FileStore) Get(ctx context.Context, productID string) (Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var r Record
+	ok, err := readJSON(s.productPath(productID), &r)
+	return r, ok, err
+}
+
+func (s *FileStore) Put(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.productPath(r.Product.ProductID), r)
+}
+
+func (s *FileStore) Delete(ctx context.Context, productID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.productPath(productID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) ProductIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries, err := ioutil.ReadDir(filepath.Join(s.Dir, productsDir))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, recordExt) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, recordExt))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *FileStore) Checkpoint(ctx context.Context) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var cp Checkpoint
+	ok, err := readJSON(filepath.Join(s.Dir, checkpointFile), &cp)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *FileStore) SaveCheckpoint(ctx context.Context, cp *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Join(s.Dir, checkpointFile)
+	if cp == nil {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return writeJSON(path, cp)
+}
+
+func (s *FileStore) HighWater(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var t time.Time
+	_, err := readJSON(filepath.Join(s.Dir, highWaterFile), &t)
+	return t, err
+}
+
+func (s *FileStore) SaveHighWater(ctx context.Context, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(filepath.Join(s.Dir, highWaterFile), t)
+}